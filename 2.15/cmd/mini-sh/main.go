@@ -1,114 +1,63 @@
 package main
 
 import (
-	"bufio"
-	"context"
-	"errors"
 	"fmt"
 	"io"
 	"os"
-	"os/signal"
 	"strings"
 
-	"wb-l2/internal/builtins"
-	"wb-l2/internal/executor"
-	"wb-l2/internal/parser"
+	"wb-l2/internal/interp"
 )
 
-func isTTY(fd uintptr) bool {
-	fi, err := os.Stdin.Stat()
+func isTTY(f *os.File) bool {
+	fi, err := f.Stat()
 	if err != nil {
 		return false
 	}
 	return (fi.Mode() & os.ModeCharDevice) != 0
 }
 
-func main() {
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt)
+const usage = "usage: mini-sh [-c command [name [arg ...]] | script [arg ...]]"
 
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		if isTTY(os.Stdin.Fd()) {
-			fmt.Print(parser.Prompt())
+func main() {
+	var (
+		name   = "mini-sh"
+		args   []string
+		input  io.Reader = os.Stdin
+		source           = ""
+	)
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "-c":
+		if len(os.Args) < 3 {
+			fmt.Fprintln(os.Stderr, "mini-sh: -c: option requires an argument")
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(2)
 		}
-
-		line, err := reader.ReadString('\n')
-		if errors.Is(err, io.EOF) {
-			fmt.Println()
-			return
+		input = strings.NewReader(os.Args[2])
+		if len(os.Args) > 3 {
+			name, args = os.Args[3], os.Args[4:]
 		}
+	case len(os.Args) > 1:
+		f, err := os.Open(os.Args[1])
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "read error: %v\n", err)
-			continue
-		}
-
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+			fmt.Fprintf(os.Stderr, "mini-sh: %v\n", err)
+			os.Exit(127)
 		}
-
-		line = os.ExpandEnv(line)
-
-		orSegments := parser.SplitPreserve(line, "||")
-		var lastOK bool
-		for idx, seg := range orSegments {
-			andSegments := parser.SplitPreserve(seg, "&&")
-			lastOK = true
-			for _, a := range andSegments {
-				a = strings.TrimSpace(a)
-				if a == "" {
-					continue
-				}
-
-				cmds, perr := parser.ParsePipeline(a)
-				if perr != nil {
-					fmt.Fprintln(os.Stderr, perr)
-					lastOK = false
-					break
-				}
-
-				ctx, cancel := context.WithCancel(context.Background())
-				done := make(chan struct{})
-				go func() {
-					select {
-					case <-sigCh:
-						cancel()
-					case <-done:
-					}
-				}()
-
-				err = executor.RunPipeline(ctx, cmds)
-				close(done)
-				cancel()
-				if err != nil {
-					lastOK = false
-					break
-				}
-			}
-			if lastOK {
-				break
-			}
-			if idx == len(orSegments)-1 {
-				// last OR segment failed; loop next prompt
-			}
-		}
-		_ = lastOK
+		defer f.Close()
+		input, source = f, os.Args[1]
+		name, args = os.Args[1], os.Args[2:]
 	}
-}
+	interactive := input == io.Reader(os.Stdin) && isTTY(os.Stdin)
 
-// builtin helpers
-func init() {
-	if len(os.Args) >= 2 {
-		switch os.Args[1] {
-		case "__builtin_ps":
-			_ = builtins.Run([]string{"ps"}, os.Stdin, os.Stdout)
-			os.Exit(0)
-		case "__builtin_kill":
-			if len(os.Args) >= 3 {
-				_ = builtins.Run([]string{"kill", os.Args[2]}, os.Stdin, os.Stdout)
-			}
-			os.Exit(0)
-		}
+	self, err := os.Executable()
+	if err != nil {
+		self = os.Args[0]
+	}
+	sh := interp.New(interp.Config{Interactive: interactive, Name: name, Args: args, Self: self, Signals: true})
+	sh.RunReader(input, source, interactive)
+	if interactive && !sh.Exiting() {
+		fmt.Println()
 	}
+	sh.RunExitTrap()
+	os.Exit(sh.ExitCode())
 }
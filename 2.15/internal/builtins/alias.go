@@ -0,0 +1,69 @@
+package builtins
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"wb-l2/internal/parser"
+)
+
+// runAlias реализует alias [name[=value] ...]: без аргументов выводит все
+// псевдонимы, name=value определяет псевдоним, name выводит его значение
+func runAlias(aliases map[string]string, args []string, out, errOut io.Writer) error {
+	if len(args) == 1 || (len(args) == 2 && args[1] == "-p") {
+		names := make([]string, 0, len(aliases))
+		for name := range aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(out, "alias %s=%s\n", name, parser.Quote(aliases[name]))
+		}
+		return nil
+	}
+
+	status := 0
+	for _, arg := range args[1:] {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !hasValue {
+			v, ok := aliases[name]
+			if !ok {
+				fmt.Fprintf(errOut, "mini-sh: alias: %s: not found\n", name)
+				status = 1
+				continue
+			}
+			fmt.Fprintf(out, "alias %s=%s\n", name, parser.Quote(v))
+			continue
+		}
+		if name == "" || strings.ContainsAny(name, " \t\n/$`\"'\\|&;<>()") {
+			fmt.Fprintf(errOut, "mini-sh: alias: `%s': invalid alias name\n", name)
+			status = 1
+			continue
+		}
+		aliases[name] = value
+	}
+	return statusError(status)
+}
+
+// runUnalias реализует unalias [-a] name ...
+func runUnalias(aliases map[string]string, args []string, errOut io.Writer) error {
+	if len(args) < 2 {
+		return fmt.Errorf("unalias: usage: unalias [-a] name [name ...]")
+	}
+	if args[1] == "-a" {
+		clear(aliases)
+		return nil
+	}
+	status := 0
+	for _, name := range args[1:] {
+		if _, ok := aliases[name]; !ok {
+			fmt.Fprintf(errOut, "mini-sh: unalias: %s: not found\n", name)
+			status = 1
+			continue
+		}
+		delete(aliases, name)
+	}
+	return statusError(status)
+}
@@ -1,70 +1,313 @@
 package builtins
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
+
+	"wb-l2/internal/jobs"
+	"wb-l2/internal/lineedit"
 )
 
+// Shell — состояние шелла, доступное встроенным командам
+type Shell interface {
+	Jobs() *jobs.Table
+	// Status возвращает код завершения последнего пайплайна ($?)
+	Status() int
+	// Exit просит шелл завершиться с указанным кодом после текущей команды
+	Exit(status int)
+	// Options возвращает изменяемую таблицу опций set -o
+	Options() map[string]bool
+	// Args и SetArgs дают доступ к позиционным параметрам $1, $2, ...
+	Args() []string
+	SetArgs(args []string)
+	// Source выполняет команды из файла в текущем шелле и возвращает код последней
+	Source(path string, args []string) int
+	// Break и Continue прерывают n вложенных циклов; вне цикла возвращают ошибку
+	Break(n int) error
+	Continue(n int) error
+
+	// Var, SetVar и UnsetVar работают с переменными шелла
+	Var(name string) (string, bool)
+	SetVar(name, value string)
+	UnsetVar(name string)
+	// Export включает или выключает передачу переменной дочерним процессам
+	Export(name string, exported bool)
+	// Environ возвращает экспортируемые переменные в формате NAME=value
+	Environ() []string
+	// History возвращает историю введённых команд
+	History() *lineedit.History
+	// Dir возвращает текущий каталог шелла, Chdir меняет его.
+	// Каталог процесса при этом не меняется
+	Dir() string
+	Chdir(dir string) error
+	// Dirs и SetDirs дают доступ к стеку каталогов pushd без текущего каталога
+	Dirs() []string
+	SetDirs(dirs []string)
+	// Fork возвращает копию состояния для встроенной команды в стадии пайплайна:
+	// как в подоболочке, её изменения не затрагивают исходный шелл
+	Fork() Shell
+	// Subshell выполняет скрипт в копии шелла с таблицей дескрипторов files
+	// и возвращает код завершения
+	Subshell(script string, files []*os.File) int
+
+	// Aliases возвращает изменяемую таблицу псевдонимов
+	Aliases() map[string]string
+	// UnsetFunc удаляет функцию шелла
+	UnsetFunc(name string)
+	// Local делает переменную локальной для выполняемой функции:
+	// при выходе из функции восстанавливается прежнее значение
+	Local(name string) error
+	// Return завершает выполняемую функцию или файл source
+	Return() error
+	// Functions возвращает определения функций шелла по именам
+	Functions() map[string]string
+
+	// Hashed возвращает изменяемую таблицу запомненных путей внешних команд
+	Hashed() map[string]*HashEntry
+	// LookPath находит исполняемый файл команды через таблицу путей и $PATH
+	LookPath(name string) (string, error)
+
+	// Traps возвращает действия ловушек по условиям: EXIT, ERR или имя сигнала без SIG
+	Traps() map[string]string
+	// SetTrap устанавливает ловушку, пустое действие игнорирует сигнал
+	SetTrap(name, action string)
+	// ResetTrap снимает ловушку и восстанавливает обработку сигнала по умолчанию
+	ResetTrap(name string)
+	// Kill посылает сигнал процессу. Сигнал самому шеллу с установленной
+	// ловушкой доставляется сразу, а не асинхронно
+	Kill(pid int, sig syscall.Signal) error
+}
+
+// exitStatus — ошибка, передающая код завершения без сообщения
+type exitStatus int
+
+func (e exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// names — встроенные команды, выполняемые в процессе шелла
+var names = []string{
+	"cd", "pwd", "echo", "kill", "ps", "jobs", "fg", "bg", "wait", "exit", "set", "source", ".", "shift",
+	"break", "continue", "test", "[",
+	"export", "unset", "env", "read",
+	"history", "alias", "unalias", "local", "return", "pushd", "popd", "dirs",
+	"trap", "type", "command", "hash", "which", ":", "true", "false",
+}
+
 func IsBuiltin(name string) bool {
-	switch name {
-	case "cd", "pwd", "echo", "kill", "ps":
-		return true
-	default:
-		return false
+	return slices.Contains(names, name)
+}
+
+// Names возвращает имена встроенных команд, например для дополнения по Tab
+func Names() []string {
+	return slices.Clone(names)
+}
+
+// Run выполняет встроенную команду и возвращает её код завершения.
+// Сообщения об ошибках выводятся в errOut
+func Run(sh Shell, args []string, in io.Reader, out, errOut io.Writer) int {
+	err := run(sh, args, in, out, errOut)
+	if err == nil {
+		return 0
+	}
+	var status exitStatus
+	if errors.As(err, &status) {
+		return int(status)
+	}
+	if errors.Is(err, syscall.EPIPE) {
+		// читатель канала завершился раньше: как внешняя команда, убитая SIGPIPE, без сообщения
+		return 128 + int(syscall.SIGPIPE)
 	}
+	fmt.Fprintf(errOut, "mini-sh: %v\n", err)
+	var testErr testError
+	if errors.As(err, &testErr) {
+		return 2
+	}
+	return 1
 }
 
-func Run(args []string, in io.Reader, out io.Writer) error {
+func run(sh Shell, args []string, in io.Reader, out, errOut io.Writer) error {
 	if len(args) == 0 {
 		return nil
 	}
 	switch args[0] {
-	case "cd":
+	case "jobs", "fg", "bg", "wait":
+		return runJobControl(sh.Jobs(), args, out, errOut)
+	case "exit":
+		status := sh.Status()
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				sh.Exit(2)
+				return fmt.Errorf("exit: %s: numeric argument required", args[1])
+			}
+			status = n & 0xff
+		}
+		sh.Exit(status)
+		return exitStatus(status)
+	case "set":
+		opts, params, ok := splitSetArgs(args[1:])
+		if !ok {
+			return runSet(sh.Options(), args, out)
+		}
+		sh.SetArgs(params)
+		if len(opts) == 0 {
+			return nil
+		}
+		return runSet(sh.Options(), append([]string{"set"}, opts...), out)
+	case "source", ".":
 		if len(args) < 2 {
-			return fmt.Errorf("cd: missing path")
+			return fmt.Errorf("%s: filename argument required", args[0])
 		}
-		return os.Chdir(args[1])
-	case "pwd":
-		cwd, err := os.Getwd()
-		if err != nil {
+		return statusError(sh.Source(args[1], args[2:]))
+	case "break", "continue":
+		n := 1
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+				return fmt.Errorf("%s: %s: loop count out of range", args[0], args[1])
+			}
+		}
+		if args[0] == "break" {
+			return sh.Break(n)
+		}
+		return sh.Continue(n)
+	case "test", "[":
+		return runTest(sh.Dir(), args)
+	case "export", "unset", "env", "read", "local":
+		return runVars(sh, args, in, out, errOut)
+	case "trap":
+		return runTrap(sh, args, out, errOut)
+	case "alias":
+		return runAlias(sh.Aliases(), args, out, errOut)
+	case "unalias":
+		return runUnalias(sh.Aliases(), args, errOut)
+	case "type":
+		return runType(sh, args, out, errOut)
+	case "command":
+		return runCommand(sh, args, in, out, errOut)
+	case "hash":
+		return runHash(sh, args, out, errOut)
+	case "which":
+		return runWhich(sh, args, out)
+	case "return":
+		status := sh.Status()
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("return: %s: numeric argument required", args[1])
+			}
+			status = n & 0xff
+		}
+		if err := sh.Return(); err != nil {
 			return err
 		}
-		_, err = fmt.Fprintln(out, cwd)
-		return err
+		return statusError(status)
+	case ":", "true":
+		// аргументы раскрываются шеллом, сама команда ничего не делает
+		return nil
+	case "false":
+		return exitStatus(1)
+	case "history":
+		return runHistory(sh.History(), args, out)
+	case "shift":
+		n := 1
+		if len(args) > 1 {
+			var err error
+			if n, err = strconv.Atoi(args[1]); err != nil || n < 0 {
+				return fmt.Errorf("shift: %s: numeric argument required", args[1])
+			}
+		}
+		params := sh.Args()
+		if n > len(params) {
+			return exitStatus(1)
+		}
+		sh.SetArgs(params[n:])
+		return nil
+	case "cd":
+		return runCd(sh, args, out)
+	case "pwd":
+		return runPwd(sh, args, out)
+	case "pushd":
+		return runPushd(sh, args, out)
+	case "popd":
+		return runPopd(sh, args, out)
+	case "dirs":
+		return runDirs(sh, args, out)
 	case "echo":
 		_, err := fmt.Fprintln(out, strings.Join(args[1:], " "))
 		return err
 	case "kill":
-		if len(args) < 2 {
-			return fmt.Errorf("kill: missing pid")
-		}
-		pid, err := strconv.Atoi(args[1])
-		if err != nil {
-			return fmt.Errorf("kill: invalid pid: %v", err)
-		}
-		return syscall.Kill(pid, syscall.SIGTERM)
+		return runKill(sh, args, out, errOut)
 	case "ps":
-		entries, err := os.ReadDir("/proc")
-		if err != nil {
-			return fmt.Errorf("ps: %v", err)
+		return runPs(args, out)
+	default:
+		return fmt.Errorf("unknown builtin: %s", args[0])
+	}
+}
+
+// splitSetArgs отделяет опции set от новых позиционных параметров,
+// которые начинаются после -- или с первого аргумента, не являющегося опцией
+func splitSetArgs(args []string) (opts, params []string, ok bool) {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--":
+			return args[:i], append([]string(nil), args[i+1:]...), true
+		case arg == "-o" || arg == "+o":
+			i++
+		case !strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "+"):
+			return args[:i], append([]string(nil), args[i:]...), true
+		}
+	}
+	return args, nil, false
+}
+
+// runSet реализует set -o/+o для включения и выключения опций
+func runSet(options map[string]bool, args []string, out io.Writer) error {
+	if len(args) == 1 || (len(args) == 2 && (args[1] == "-o" || args[1] == "+o")) {
+		names := make([]string, 0, len(options))
+		for name := range options {
+			names = append(names, name)
 		}
-		for _, e := range entries {
-			if !e.IsDir() {
+		sort.Strings(names)
+		for _, name := range names {
+			if len(args) == 2 && args[1] == "+o" {
+				sign := "+"
+				if options[name] {
+					sign = "-"
+				}
+				fmt.Fprintf(out, "set %so %s\n", sign, name)
 				continue
 			}
-			if _, err := strconv.Atoi(e.Name()); err == nil {
-				cmdline, _ := os.ReadFile(filepath.Join("/proc", e.Name(), "cmdline"))
-				line := strings.ReplaceAll(string(cmdline), "\x00", " ")
-				fmt.Fprintf(out, "%s %s\n", e.Name(), strings.TrimSpace(line))
+			state := "off"
+			if options[name] {
+				state = "on"
 			}
+			fmt.Fprintf(out, "%-15s\t%s\n", name, state)
 		}
 		return nil
-	default:
-		return fmt.Errorf("unknown builtin: %s", args[0])
 	}
+
+	for i := 1; i < len(args); i++ {
+		if args[i] != "-o" && args[i] != "+o" {
+			return fmt.Errorf("set: %s: invalid option", args[i])
+		}
+		if i+1 >= len(args) {
+			return fmt.Errorf("set: %s: option name required", args[i])
+		}
+		name := args[i+1]
+		if _, ok := options[name]; !ok {
+			return fmt.Errorf("set: %s: invalid option name", name)
+		}
+		options[name] = args[i] == "-o"
+		i++
+	}
+	return nil
 }
@@ -0,0 +1,342 @@
+package builtins
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// runCd реализует cd [-L|-P] [dir]. Без аргумента переходит в $HOME,
+// cd - — в $OLDPWD. Относительный путь ищется также в каталогах $CDPATH.
+// Если новый каталог не очевиден из аргумента, он выводится
+func runCd(sh Shell, args []string, out io.Writer) error {
+	physical, args, err := pathMode("cd", args[1:])
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 {
+		return errors.New("cd: too many arguments")
+	}
+
+	var dir string
+	show := false
+	switch {
+	case len(args) == 0:
+		home, ok := sh.Var("HOME")
+		if !ok || home == "" {
+			return errors.New("cd: HOME not set")
+		}
+		dir = home
+	case args[0] == "-":
+		old, ok := sh.Var("OLDPWD")
+		if !ok || old == "" {
+			return errors.New("cd: OLDPWD not set")
+		}
+		dir, show = old, true
+	default:
+		dir = args[0]
+		if found, ok := searchCdpath(sh, dir); ok {
+			dir, show = found, true
+		}
+	}
+
+	if err := changeDir(sh, dir, physical); err != nil {
+		return fmt.Errorf("cd: %v", err)
+	}
+	if show {
+		pwd, _ := sh.Var("PWD")
+		fmt.Fprintln(out, pwd)
+	}
+	return nil
+}
+
+// pathMode разбирает опции -L и -P, последняя из них побеждает
+func pathMode(name string, args []string) (physical bool, rest []string, err error) {
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") && args[0] != "-" {
+		arg := args[0]
+		args = args[1:]
+		if arg == "--" {
+			break
+		}
+		for _, c := range arg[1:] {
+			switch c {
+			case 'L':
+				physical = false
+			case 'P':
+				physical = true
+			default:
+				return false, nil, fmt.Errorf("%s: -%c: invalid option\n%s: usage: %s [-L|-P] [dir]", name, c, name, name)
+			}
+		}
+	}
+	return physical, args, nil
+}
+
+// searchCdpath ищет относительный каталог в $CDPATH. Пути, начинающиеся
+// с / . или .., в $CDPATH не ищутся. Найденный через пустой элемент
+// (текущий каталог) путь не считается найденным, чтобы cd его не выводил
+func searchCdpath(sh Shell, dir string) (string, bool) {
+	cdpath, _ := sh.Var("CDPATH")
+	if cdpath == "" || filepath.IsAbs(dir) || dir == "." || dir == ".." ||
+		strings.HasPrefix(dir, "./") || strings.HasPrefix(dir, "../") {
+		return "", false
+	}
+	for _, base := range filepath.SplitList(cdpath) {
+		if base == "" {
+			base = "."
+		}
+		candidate := filepath.Join(base, dir)
+		if fi, err := os.Stat(logicalPath(currentDir(sh), candidate)); err == nil && fi.IsDir() {
+			return candidate, base != "."
+		}
+	}
+	return "", false
+}
+
+// logicalPath возвращает путь dir относительно логического текущего каталога base:
+// .. убирает предыдущий элемент пути, а не переходит к родителю цели символьной ссылки
+func logicalPath(base, dir string) string {
+	if filepath.IsAbs(dir) {
+		return filepath.Clean(dir)
+	}
+	return filepath.Join(base, dir)
+}
+
+// currentDir возвращает $PWD, если он указывает на текущий каталог, иначе каталог шелла
+func currentDir(sh Shell) string {
+	if pwd, ok := sh.Var("PWD"); ok && filepath.IsAbs(pwd) && sameFile(pwd, sh.Dir()) {
+		return pwd
+	}
+	return sh.Dir()
+}
+
+func sameFile(a, b string) bool {
+	fa, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	fb, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fa, fb)
+}
+
+// changeDir меняет текущий каталог и обновляет $PWD и $OLDPWD. В логическом
+// режиме $PWD получает путь с символьными ссылками; если такого пути
+// нет, как и в физическом режиме, ссылки раскрываются по ходу пути
+func changeDir(sh Shell, dir string, physical bool) error {
+	old := currentDir(sh)
+	target := logicalPath(old, dir)
+	if physical || sh.Chdir(target) != nil {
+		target = dir
+		if !filepath.IsAbs(dir) {
+			wd, err := filepath.EvalSymlinks(sh.Dir())
+			if err != nil {
+				return err
+			}
+			// без Join: он убрал бы .. до раскрытия символьных ссылок
+			target = wd + "/" + dir
+		}
+		resolved, err := filepath.EvalSymlinks(target)
+		if err == nil {
+			err = sh.Chdir(resolved)
+		}
+		if err != nil {
+			var pathErr *os.PathError
+			if errors.As(err, &pathErr) {
+				return fmt.Errorf("%s: %v", dir, pathErr.Err)
+			}
+			return err
+		}
+		target = resolved
+	}
+	sh.SetVar("OLDPWD", old)
+	sh.Export("OLDPWD", true)
+	sh.SetVar("PWD", target)
+	sh.Export("PWD", true)
+	return nil
+}
+
+// runPwd реализует pwd [-L|-P]: логический путь из $PWD или путь без символьных ссылок
+func runPwd(sh Shell, args []string, out io.Writer) error {
+	physical, args, err := pathMode("pwd", args[1:])
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return errors.New("pwd: too many arguments")
+	}
+	dir := currentDir(sh)
+	if physical {
+		if dir, err = filepath.EvalSymlinks(sh.Dir()); err != nil {
+			return fmt.Errorf("pwd: %v", err)
+		}
+	}
+	_, err = fmt.Fprintln(out, dir)
+	return err
+}
+
+// runPushd реализует pushd [dir | +N | -N]: dir переходит в каталог и кладёт
+// прежний в стек, +N и -N поворачивают стек, без аргумента меняются местами
+// два верхних каталога. После перехода выводится стек
+func runPushd(sh Shell, args []string, out io.Writer) error {
+	stack := dirStack(sh)
+	if len(args) > 2 {
+		return errors.New("pushd: too many arguments")
+	}
+	switch {
+	case len(args) == 1:
+		if len(stack) < 2 {
+			return errors.New("pushd: no other directory")
+		}
+		stack[0], stack[1] = stack[1], stack[0]
+	case isStackIndex(args[1]):
+		n, err := stackIndex("pushd", args[1], len(stack))
+		if err != nil {
+			return err
+		}
+		stack = append(stack[n:], stack[:n]...)
+	default:
+		if err := changeDir(sh, args[1], false); err != nil {
+			return fmt.Errorf("pushd: %v", err)
+		}
+		sh.SetDirs(stack)
+		return printDirs(sh, out, false, false, false)
+	}
+	if err := changeDir(sh, stack[0], false); err != nil {
+		return fmt.Errorf("pushd: %v", err)
+	}
+	sh.SetDirs(stack[1:])
+	return printDirs(sh, out, false, false, false)
+}
+
+// runPopd реализует popd [+N | -N]: без аргумента убирает верхний каталог
+// стека и переходит в следующий, +N и -N убирают N-й каталог
+func runPopd(sh Shell, args []string, out io.Writer) error {
+	stack := dirStack(sh)
+	if len(args) > 2 {
+		return errors.New("popd: too many arguments")
+	}
+	if len(stack) < 2 {
+		return errors.New("popd: directory stack empty")
+	}
+	n := 0
+	if len(args) == 2 {
+		if !isStackIndex(args[1]) {
+			return fmt.Errorf("popd: %s: invalid argument", args[1])
+		}
+		var err error
+		if n, err = stackIndex("popd", args[1], len(stack)); err != nil {
+			return err
+		}
+	}
+	if n == 0 {
+		if err := changeDir(sh, stack[1], false); err != nil {
+			return fmt.Errorf("popd: %v", err)
+		}
+	}
+	stack = append(stack[:n], stack[n+1:]...)
+	sh.SetDirs(stack[1:])
+	return printDirs(sh, out, false, false, false)
+}
+
+// runDirs реализует dirs [-c] [-l] [-p] [-v] [+N | -N]
+func runDirs(sh Shell, args []string, out io.Writer) error {
+	long, perLine, numbered := false, false, false
+	index := ""
+	for _, arg := range args[1:] {
+		switch {
+		case isStackIndex(arg):
+			index = arg
+		case arg == "-c":
+			sh.SetDirs(nil)
+			return nil
+		case arg == "-l":
+			long = true
+		case arg == "-p":
+			perLine = true
+		case arg == "-v":
+			perLine, numbered = true, true
+		default:
+			return fmt.Errorf("dirs: %s: invalid option\ndirs: usage: dirs [-clpv] [+N] [-N]", arg)
+		}
+	}
+	if index != "" {
+		stack := dirStack(sh)
+		n, err := stackIndex("dirs", index, len(stack))
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, abbreviateHome(sh, stack[n], long))
+		return err
+	}
+	return printDirs(sh, out, long, perLine, numbered)
+}
+
+// dirStack возвращает стек каталогов целиком: текущий каталог и сохранённые
+func dirStack(sh Shell) []string {
+	return append([]string{currentDir(sh)}, sh.Dirs()...)
+}
+
+func isStackIndex(arg string) bool {
+	return len(arg) > 1 && (arg[0] == '+' || arg[0] == '-') && isNumber(arg[1:])
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil && !strings.HasPrefix(s, "-") && !strings.HasPrefix(s, "+")
+}
+
+// stackIndex переводит +N (слева) или -N (справа) в индекс стека размера size
+func stackIndex(name, arg string, size int) (int, error) {
+	n, _ := strconv.Atoi(arg[1:])
+	if n >= size {
+		return 0, fmt.Errorf("%s: %s: directory stack index out of range", name, arg)
+	}
+	if arg[0] == '-' {
+		n = size - 1 - n
+	}
+	return n, nil
+}
+
+// printDirs выводит стек каталогов в строку или по одному в строке, с номерами при numbered.
+// Без long домашний каталог сокращается до ~
+func printDirs(sh Shell, out io.Writer, long, perLine, numbered bool) error {
+	stack := dirStack(sh)
+	for i, dir := range stack {
+		stack[i] = abbreviateHome(sh, dir, long)
+	}
+	var err error
+	switch {
+	case numbered:
+		for i, dir := range stack {
+			if _, err = fmt.Fprintf(out, "%2d  %s\n", i, dir); err != nil {
+				break
+			}
+		}
+	case perLine:
+		_, err = fmt.Fprintln(out, strings.Join(stack, "\n"))
+	default:
+		_, err = fmt.Fprintln(out, strings.Join(stack, " "))
+	}
+	return err
+}
+
+func abbreviateHome(sh Shell, dir string, long bool) string {
+	home, _ := sh.Var("HOME")
+	if long || home == "" || home == "/" {
+		return dir
+	}
+	if dir == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(dir, home+"/"); ok {
+		return "~/" + rest
+	}
+	return dir
+}
@@ -0,0 +1,31 @@
+package builtins
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"wb-l2/internal/lineedit"
+)
+
+// runHistory реализует history [N] и history -c: выводит историю с номерами
+// строк, последние N строк или очищает её
+func runHistory(h *lineedit.History, args []string, out io.Writer) error {
+	entries := h.Entries()
+	first := 0
+	if len(args) > 1 {
+		if args[1] == "-c" {
+			h.Clear()
+			return nil
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			return fmt.Errorf("history: %s: numeric argument required", args[1])
+		}
+		first = max(0, len(entries)-n)
+	}
+	for i := first; i < len(entries); i++ {
+		fmt.Fprintf(out, "%5d  %s\n", i+1, entries[i])
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package builtins
+
+import (
+	"fmt"
+	"io"
+
+	"wb-l2/internal/jobs"
+)
+
+// runJobControl реализует jobs, fg, bg и wait
+func runJobControl(table *jobs.Table, args []string, out, errOut io.Writer) error {
+	table.Reap()
+
+	switch args[0] {
+	case "jobs":
+		for _, j := range table.List() {
+			if len(args) > 1 && !matchesAny(table, j, args[1:]) {
+				continue
+			}
+			fmt.Fprintln(out, table.Format(j))
+		}
+		return nil
+
+	case "fg":
+		j, err := table.Find(jobSpec(args))
+		if err != nil {
+			return fmt.Errorf("fg: %v", err)
+		}
+		fmt.Fprintln(out, j.Text)
+		status := table.Foreground(j, true)
+		if j.State() == jobs.Stopped {
+			fmt.Fprintf(errOut, "\n%s\n", table.Format(j))
+		}
+		return statusError(status)
+
+	case "bg":
+		j, err := table.Find(jobSpec(args))
+		if err != nil {
+			return fmt.Errorf("bg: %v", err)
+		}
+		if j.State() != jobs.Stopped {
+			return fmt.Errorf("bg: job %d already in background", j.ID)
+		}
+		if err := table.Background(j); err != nil {
+			return fmt.Errorf("bg: %v", err)
+		}
+		fmt.Fprintf(out, "[%d]+ %s &\n", j.ID, j.Text)
+		return nil
+
+	case "wait":
+		if len(args) == 1 {
+			for _, j := range table.List() {
+				table.Wait(j)
+			}
+			return nil
+		}
+		var status int
+		for _, spec := range args[1:] {
+			j, err := table.Find(spec)
+			if err != nil {
+				return fmt.Errorf("wait: %v", err)
+			}
+			status = table.Wait(j)
+		}
+		return statusError(status)
+	}
+	return nil
+}
+
+// statusError превращает код завершения в ошибку для Run
+func statusError(status int) error {
+	if status == 0 {
+		return nil
+	}
+	return exitStatus(status)
+}
+
+func jobSpec(args []string) string {
+	if len(args) > 1 {
+		return args[1]
+	}
+	return ""
+}
+
+func matchesAny(table *jobs.Table, j *jobs.Job, specs []string) bool {
+	for _, spec := range specs {
+		if found, err := table.Find(spec); err == nil && found == j {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package builtins
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"wb-l2/internal/jobs"
+)
+
+// runKill реализует kill [-s SIG | -n NUM | -SIG] цель ... и kill -l [сигнал|код].
+// Цель — PID, -PGID для группы процессов или %задание; заданию сигнал
+// посылается всей его группе. Ошибка для одной цели не мешает остальным
+func runKill(sh Shell, args []string, out, errOut io.Writer) error {
+	args = args[1:]
+	sig := syscall.SIGTERM
+	if len(args) > 0 {
+		switch arg := args[0]; {
+		case arg == "-l" || arg == "-L":
+			return listSignals(args[1:], out)
+		case arg == "-s" || arg == "-n":
+			if len(args) < 2 {
+				return fmt.Errorf("kill: %s: option requires an argument", arg)
+			}
+			s, ok := ParseSignal(args[1])
+			if !ok {
+				return fmt.Errorf("kill: %s: invalid signal specification", args[1])
+			}
+			sig, args = s, args[2:]
+		case arg == "--":
+			args = args[1:]
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// -9 — номер сигнала, а не группа процессов, если за ним есть цели
+			s, ok := ParseSignal(arg[1:])
+			if !ok {
+				return fmt.Errorf("kill: %s: invalid signal specification", arg[1:])
+			}
+			sig, args = s, args[1:]
+			if len(args) > 0 && args[0] == "--" {
+				args = args[1:]
+			}
+		}
+	}
+	if len(args) == 0 {
+		return errors.New("kill: usage: kill [-s sigspec | -n signum | -sigspec] pid | %job ... or kill -l [sigspec]")
+	}
+
+	table := sh.Jobs()
+	table.Reap()
+	status := 0
+	for _, target := range args {
+		if err := killTarget(sh, table, target, sig); err != nil {
+			fmt.Fprintf(errOut, "mini-sh: kill: %v\n", err)
+			status = 1
+		}
+	}
+	return statusError(status)
+}
+
+// killTarget посылает сигнал одной цели
+func killTarget(sh Shell, table *jobs.Table, target string, sig syscall.Signal) error {
+	if strings.HasPrefix(target, "%") {
+		j, err := table.Find(target)
+		if err != nil {
+			return err
+		}
+		if err := syscall.Kill(-j.Pgid, sig); err != nil {
+			return fmt.Errorf("%s: %v", target, err)
+		}
+		// остановленное задание не обработает сигнал завершения, пока не продолжится
+		if j.State() == jobs.Stopped && (sig == syscall.SIGTERM || sig == syscall.SIGHUP) {
+			_ = syscall.Kill(-j.Pgid, syscall.SIGCONT)
+		}
+		return nil
+	}
+	pid, err := strconv.Atoi(target)
+	if err != nil {
+		return fmt.Errorf("%s: arguments must be process or job IDs", target)
+	}
+	if err := sh.Kill(pid, sig); err != nil {
+		return fmt.Errorf("(%d) - %v", pid, err)
+	}
+	return nil
+}
+
+// listSignals выводит таблицу сигналов или переводит номер в имя и обратно.
+// Код завершения больше 128 переводится в имя убившего процесс сигнала
+func listSignals(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		for n := 1; n < len(signalNames); n++ {
+			sep := "\t"
+			if n%5 == 0 || n == len(signalNames)-1 {
+				sep = "\n"
+			}
+			fmt.Fprintf(out, "%2d) SIG%-7s%s", n, signalNames[n], sep)
+		}
+		return nil
+	}
+	for _, arg := range args {
+		if n, err := strconv.Atoi(arg); err == nil {
+			if n > 128 {
+				n -= 128
+			}
+			sig, ok := ParseSignal(strconv.Itoa(n))
+			if !ok || n == 0 {
+				return fmt.Errorf("kill: %s: invalid signal specification", arg)
+			}
+			fmt.Fprintln(out, SignalName(sig))
+			continue
+		}
+		sig, ok := ParseSignal(arg)
+		if !ok {
+			return fmt.Errorf("kill: %s: invalid signal specification", arg)
+		}
+		fmt.Fprintln(out, int(sig))
+	}
+	return nil
+}
@@ -0,0 +1,447 @@
+package builtins
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"wb-l2/internal/parser"
+)
+
+// HashEntry — запомненный путь внешней команды и число её запусков
+type HashEntry struct {
+	Path string
+	Hits int
+}
+
+// SearchPath ищет исполняемый файл name в каталогах списка path.
+// Относительные каталоги отсчитываются от dir, а найденные в них пути
+// остаются относительными. Без all возвращается только первое совпадение
+func SearchPath(name, path, dir string, all bool) []string {
+	var found []string
+	for _, elem := range filepath.SplitList(path) {
+		if elem == "" {
+			elem = "."
+		}
+		file := filepath.Join(elem, name)
+		if !strings.Contains(file, "/") {
+			file = "./" + file
+		}
+		if IsExecutable(resolve(dir, file)) {
+			found = append(found, file)
+			if !all {
+				break
+			}
+		}
+	}
+	return found
+}
+
+// Executables перечисляет имена исполняемых файлов в каталогах списка path,
+// начинающиеся с prefix. Относительные каталоги отсчитываются от wd
+func Executables(path, wd, prefix string) []string {
+	var out []string
+	for _, dir := range filepath.SplitList(path) {
+		dir = resolve(wd, dir)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), prefix) && !e.IsDir() && IsExecutable(filepath.Join(dir, e.Name())) {
+				out = append(out, e.Name())
+			}
+		}
+	}
+	return out
+}
+
+// resolve возвращает путь path относительно каталога dir
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// IsExecutable сообщает, является ли путь исполняемым обычным файлом
+func IsExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.Mode().IsRegular() && fi.Mode()&0o111 != 0
+}
+
+// Suggest возвращает до трёх команд с именами, похожими на name: псевдонимы,
+// функции, встроенные команды и файлы из $PATH на расстоянии не больше одной
+// правки для имён до пяти символов и двух для длинных. Для однобуквенных
+// имён похожими оказываются почти все короткие команды, поэтому их нет
+func Suggest(sh Shell, name string) []string {
+	if len(name) < 2 {
+		return nil
+	}
+	limit := 2
+	if len(name) <= 5 {
+		limit = 1
+	}
+	candidates := slices.Concat(
+		slices.Collect(maps.Keys(sh.Aliases())),
+		slices.Collect(maps.Keys(sh.Functions())),
+		names,
+	)
+	if path, ok := sh.Var("PATH"); ok {
+		candidates = append(candidates, Executables(path, sh.Dir(), "")...)
+	}
+
+	dist := make(map[string]int)
+	for _, c := range candidates {
+		if _, ok := dist[c]; ok || c == name {
+			continue
+		}
+		if d := editDistance(name, c); d <= limit {
+			dist[c] = d
+		}
+	}
+	out := slices.Collect(maps.Keys(dist))
+	slices.SortFunc(out, func(a, b string) int {
+		if dist[a] != dist[b] {
+			return dist[a] - dist[b]
+		}
+		return strings.Compare(a, b)
+	})
+	return out[:min(len(out), 3)]
+}
+
+// editDistance считает расстояние Дамерау — Левенштейна между строками:
+// вставка, удаление, замена и перестановка соседних символов стоят по единице
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// commandKind описывает, чем является имя команды: kind — слово для type -t,
+// text — значение псевдонима, определение функции или путь к файлу
+type commandKind struct {
+	kind string
+	text string
+}
+
+// describe перечисляет значения имени в порядке поиска: псевдоним, ключевое
+// слово, функция, встроенная команда, файлы. Без all поиск останавливается
+// на первом совпадении
+func describe(sh Shell, name string, all bool) []commandKind {
+	var out []commandKind
+	if v, ok := sh.Aliases()[name]; ok {
+		out = append(out, commandKind{"alias", v})
+	}
+	if parser.IsKeyword(name) {
+		out = append(out, commandKind{"keyword", ""})
+	}
+	if text, ok := sh.Functions()[name]; ok {
+		out = append(out, commandKind{"function", text})
+	}
+	if IsBuiltin(name) {
+		out = append(out, commandKind{"builtin", ""})
+	}
+	if len(out) > 0 && !all {
+		return out[:1]
+	}
+	if strings.Contains(name, "/") {
+		if IsExecutable(resolve(sh.Dir(), name)) {
+			out = append(out, commandKind{"file", name})
+		}
+		return out
+	}
+	if e, ok := sh.Hashed()[name]; ok && !all {
+		return append(out, commandKind{"hashed", e.Path})
+	}
+	path, _ := sh.Var("PATH")
+	for _, file := range SearchPath(name, path, sh.Dir(), all) {
+		out = append(out, commandKind{"file", file})
+	}
+	return out
+}
+
+// String возвращает описание в формате type без опций
+func (c commandKind) String(name string) string {
+	switch c.kind {
+	case "alias":
+		return fmt.Sprintf("%s is aliased to `%s'", name, c.text)
+	case "keyword":
+		return name + " is a shell keyword"
+	case "function":
+		return fmt.Sprintf("%s is a function\n%s", name, c.text)
+	case "builtin":
+		return name + " is a shell builtin"
+	case "hashed":
+		return fmt.Sprintf("%s is hashed (%s)", name, c.text)
+	default:
+		return fmt.Sprintf("%s is %s", name, c.text)
+	}
+}
+
+// runType реализует type [-afpPt] name ...: -t выводит только вид команды,
+// -p и -P — путь к файлу, -a — все найденные значения
+func runType(sh Shell, args []string, out, errOut io.Writer) error {
+	var all, kindOnly, pathOnly, forcePath bool
+	i := 1
+	for ; i < len(args) && len(args[i]) > 1 && args[i][0] == '-'; i++ {
+		if args[i] == "--" {
+			i++
+			break
+		}
+		for _, c := range args[i][1:] {
+			switch c {
+			case 'a':
+				all = true
+			case 't':
+				kindOnly = true
+			case 'p':
+				pathOnly = true
+			case 'P':
+				pathOnly, forcePath = true, true
+			case 'f':
+			default:
+				return fmt.Errorf("type: -%c: invalid option\ntype: usage: type [-afptP] name [name ...]", c)
+			}
+		}
+	}
+
+	status := 0
+	for _, name := range args[i:] {
+		kinds := describe(sh, name, all || forcePath)
+		if forcePath {
+			kinds = slices.DeleteFunc(kinds, func(c commandKind) bool { return c.kind != "file" })
+			if !all && len(kinds) > 1 {
+				kinds = kinds[:1]
+			}
+		}
+		if len(kinds) == 0 {
+			if !kindOnly && !pathOnly {
+				fmt.Fprintf(errOut, "mini-sh: type: %s: not found\n", name)
+			}
+			status = 1
+			continue
+		}
+		for _, c := range kinds {
+			switch {
+			case kindOnly:
+				kind := c.kind
+				if kind == "hashed" {
+					kind = "file"
+				}
+				fmt.Fprintln(out, kind)
+			case pathOnly:
+				if c.kind == "file" || c.kind == "hashed" {
+					fmt.Fprintln(out, c.text)
+				}
+			default:
+				fmt.Fprintln(out, c.String(name))
+			}
+		}
+	}
+	return statusError(status)
+}
+
+// runCommand реализует command -v и command -V: -v выводит команду, которая
+// будет выполнена, -V описывает её как type. Запуск command name [args]
+// без функций и псевдонимов выполняет интерпретатор, сюда попадают только
+// встроенные команды
+func runCommand(sh Shell, args []string, in io.Reader, out, errOut io.Writer) error {
+	i := 1
+	verbose, brief := false, false
+	for ; i < len(args) && len(args[i]) > 1 && args[i][0] == '-'; i++ {
+		if args[i] == "--" {
+			i++
+			break
+		}
+		for _, c := range args[i][1:] {
+			switch c {
+			case 'v':
+				brief = true
+			case 'V':
+				verbose = true
+			case 'p':
+			default:
+				return fmt.Errorf("command: -%c: invalid option\ncommand: usage: command [-pVv] command [arg ...]", c)
+			}
+		}
+	}
+	if i == len(args) {
+		return nil
+	}
+	if !brief && !verbose {
+		if !IsBuiltin(args[i]) {
+			return fmt.Errorf("command: %s: not a shell builtin", args[i])
+		}
+		return run(sh, args[i:], in, out, errOut)
+	}
+
+	status := 0
+	for _, name := range args[i:] {
+		kinds := describe(sh, name, false)
+		if len(kinds) == 0 {
+			if verbose {
+				fmt.Fprintf(errOut, "mini-sh: command: %s: not found\n", name)
+			}
+			status = 1
+			continue
+		}
+		c := kinds[0]
+		switch {
+		case verbose:
+			fmt.Fprintln(out, c.String(name))
+		case c.kind == "alias":
+			fmt.Fprintf(out, "alias %s=%s\n", name, parser.Quote(c.text))
+		case c.kind == "file" || c.kind == "hashed":
+			fmt.Fprintln(out, resolve(sh.Dir(), c.text))
+		default:
+			fmt.Fprintln(out, name)
+		}
+	}
+	return statusError(status)
+}
+
+// runWhich реализует which [-a] name ...: выводит пути к исполняемым файлам,
+// без учёта псевдонимов, функций и встроенных команд
+func runWhich(sh Shell, args []string, out io.Writer) error {
+	all := false
+	i := 1
+	if i < len(args) && args[i] == "-a" {
+		all = true
+		i++
+	}
+	path, _ := sh.Var("PATH")
+	status := 0
+	for _, name := range args[i:] {
+		var found []string
+		if strings.Contains(name, "/") {
+			if IsExecutable(resolve(sh.Dir(), name)) {
+				found = []string{name}
+			}
+		} else {
+			found = SearchPath(name, path, sh.Dir(), all)
+		}
+		if len(found) == 0 {
+			status = 1
+		}
+		for _, file := range found {
+			fmt.Fprintln(out, file)
+		}
+	}
+	return statusError(status)
+}
+
+// runHash реализует hash [-r] [-d] [-t] [-p path] [name ...]: без аргументов
+// выводит таблицу запомненных путей, -r очищает её, -d удаляет имена,
+// -t выводит пути, -p запоминает заданный путь, а имена ищутся в $PATH заново
+func runHash(sh Shell, args []string, out, errOut io.Writer) error {
+	table := sh.Hashed()
+	var reset, remove, show bool
+	setPath := ""
+	i := 1
+	for ; i < len(args) && len(args[i]) > 1 && args[i][0] == '-'; i++ {
+		if args[i] == "--" {
+			i++
+			break
+		}
+		for _, c := range args[i][1:] {
+			switch c {
+			case 'r':
+				reset = true
+			case 'd':
+				remove = true
+			case 't':
+				show = true
+			case 'p':
+				if i+1 >= len(args) {
+					return fmt.Errorf("hash: -p: option requires an argument")
+				}
+				i++
+				setPath = args[i]
+			default:
+				return fmt.Errorf("hash: -%c: invalid option\nhash: usage: hash [-r] [-p pathname] [-dt] [name ...]", c)
+			}
+		}
+	}
+	names := args[i:]
+	if reset {
+		clear(table)
+	}
+	if len(names) == 0 {
+		if reset {
+			return nil
+		}
+		if show || remove || setPath != "" {
+			return fmt.Errorf("hash: argument expected")
+		}
+		if len(table) == 0 {
+			fmt.Fprintln(out, "hash: hash table empty")
+			return nil
+		}
+		fmt.Fprintln(out, "hits\tcommand")
+		for _, name := range slices.Sorted(maps.Keys(table)) {
+			fmt.Fprintf(out, "%4d\t%s\n", table[name].Hits, table[name].Path)
+		}
+		return nil
+	}
+
+	path, _ := sh.Var("PATH")
+	status := 0
+	for _, name := range names {
+		switch {
+		case setPath != "":
+			table[name] = &HashEntry{Path: setPath}
+		case remove:
+			if _, ok := table[name]; !ok {
+				fmt.Fprintf(errOut, "mini-sh: hash: %s: not found\n", name)
+				status = 1
+			}
+			delete(table, name)
+		case show:
+			e, ok := table[name]
+			if !ok {
+				fmt.Fprintf(errOut, "mini-sh: hash: %s: not found\n", name)
+				status = 1
+				continue
+			}
+			if len(names) > 1 {
+				fmt.Fprintf(out, "%s\t", name)
+			}
+			fmt.Fprintln(out, e.Path)
+		case strings.Contains(name, "/"), IsBuiltin(name):
+			// пути и встроенные команды не запоминаются
+		default:
+			found := SearchPath(name, path, sh.Dir(), false)
+			if len(found) == 0 {
+				fmt.Fprintf(errOut, "mini-sh: hash: %s: not found\n", name)
+				status = 1
+				continue
+			}
+			table[name] = &HashEntry{Path: resolve(sh.Dir(), found[0])}
+		}
+	}
+	return statusError(status)
+}
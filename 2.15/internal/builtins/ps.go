@@ -0,0 +1,246 @@
+package builtins
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// clockTicks — единица времени в /proc/<pid>/stat (USER_HZ), на Linux всегда 100
+const clockTicks = 100
+
+// procInfo — сведения о процессе из /proc/<pid>/stat, status и cmdline
+type procInfo struct {
+	pid, ppid, pgid int
+	state           string
+	uid             string
+	user            string
+	// rss — резидентная память в КиБ, cpu — суммарное время в user и kernel
+	rss   int64
+	cpu   time.Duration
+	start time.Time
+	cmd   string
+}
+
+// psKeys — ключи сортировки ps -k
+var psKeys = map[string]func(a, b *procInfo) int{
+	"pid":   func(a, b *procInfo) int { return cmp.Compare(a.pid, b.pid) },
+	"ppid":  func(a, b *procInfo) int { return cmp.Compare(a.ppid, b.ppid) },
+	"pgid":  func(a, b *procInfo) int { return cmp.Compare(a.pgid, b.pgid) },
+	"user":  func(a, b *procInfo) int { return cmp.Compare(a.user, b.user) },
+	"state": func(a, b *procInfo) int { return cmp.Compare(a.state, b.state) },
+	"rss":   func(a, b *procInfo) int { return cmp.Compare(a.rss, b.rss) },
+	"time":  func(a, b *procInfo) int { return cmp.Compare(a.cpu, b.cpu) },
+	"start": func(a, b *procInfo) int { return a.start.Compare(b.start) },
+	"cmd":   func(a, b *procInfo) int { return cmp.Compare(a.cmd, b.cmd) },
+}
+
+// runPs реализует ps [-u user,...] [-p pid,...] [-g pgid,...] [-s state,...] [-k [-]key]:
+// выводит процессы из /proc с фильтрами по пользователю, PID, группе
+// и состоянию, сортирует по ключу (с минусом — по убыванию)
+func runPs(args []string, out io.Writer) error {
+	var (
+		users, pids, pgids, states []string
+		sortKey                    = "pid"
+	)
+	for i := 1; i < len(args); i++ {
+		opt := args[i]
+		if !slices.Contains([]string{"-u", "-p", "-g", "-s", "-k"}, opt) {
+			return fmt.Errorf("ps: %s: invalid option\nps: usage: ps [-u user,...] [-p pid,...] [-g pgid,...] [-s state,...] [-k [-]key]", opt)
+		}
+		if i+1 >= len(args) {
+			return fmt.Errorf("ps: %s: option requires an argument", opt)
+		}
+		values := strings.Split(args[i+1], ",")
+		switch opt {
+		case "-u":
+			users = append(users, values...)
+		case "-p":
+			pids = append(pids, values...)
+		case "-g":
+			pgids = append(pgids, values...)
+		case "-s":
+			states = append(states, values...)
+		case "-k":
+			sortKey = args[i+1]
+		}
+		i++
+	}
+	compare, ok := psKeys[strings.TrimPrefix(sortKey, "-")]
+	if !ok {
+		return fmt.Errorf("ps: %s: unknown sort key", sortKey)
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return fmt.Errorf("ps: %v", err)
+	}
+	boot := bootTime()
+	names := make(map[string]string)
+	var procs []*procInfo
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil || !e.IsDir() {
+			continue
+		}
+		// процесс мог завершиться между чтением каталога и его файлов
+		p, err := readProc(e.Name(), boot, names)
+		if err != nil {
+			continue
+		}
+		if matchFilter(users, p.user, p.uid) && matchFilter(pids, strconv.Itoa(p.pid)) &&
+			matchFilter(pgids, strconv.Itoa(p.pgid)) && matchFilter(states, p.state) {
+			procs = append(procs, p)
+		}
+	}
+	slices.SortStableFunc(procs, func(a, b *procInfo) int {
+		if strings.HasPrefix(sortKey, "-") {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+
+	fmt.Fprintf(out, "%7s %7s %7s %-8s %-4s %8s %8s %-5s %s\n",
+		"PID", "PPID", "PGID", "USER", "STAT", "RSS", "TIME", "START", "CMD")
+	now := time.Now()
+	for _, p := range procs {
+		_, err := fmt.Fprintf(out, "%7d %7d %7d %-8s %-4s %8d %8s %-5s %s\n",
+			p.pid, p.ppid, p.pgid, p.user, p.state, p.rss, formatCPU(p.cpu), formatStart(p.start, now), p.cmd)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchFilter сообщает, совпадает ли одно из значений с фильтром; пустой фильтр пропускает всё
+func matchFilter(filter []string, values ...string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if slices.Contains(filter, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// readProc читает сведения о процессе. names кеширует имена пользователей по UID
+func readProc(pid string, boot time.Time, names map[string]string) (*procInfo, error) {
+	dir := filepath.Join("/proc", pid)
+	stat, err := os.ReadFile(filepath.Join(dir, "stat"))
+	if err != nil {
+		return nil, err
+	}
+	// имя команды в скобках может содержать пробелы и скобки,
+	// поэтому поля отсчитываются от последней )
+	text := string(stat)
+	open, end := strings.IndexByte(text, '('), strings.LastIndexByte(text, ')')
+	if open < 0 || end < open {
+		return nil, fmt.Errorf("%s: malformed stat", pid)
+	}
+	comm := text[open+1 : end]
+	// поля после имени: state ppid pgrp session tty_nr tpgid flags minflt cminflt
+	// majflt cmajflt utime stime cutime cstime priority nice num_threads
+	// itrealvalue starttime vsize rss
+	fields := strings.Fields(text[end+1:])
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("%s: malformed stat", pid)
+	}
+	num := func(i int) int64 {
+		n, _ := strconv.ParseInt(fields[i], 10, 64)
+		return n
+	}
+
+	p := &procInfo{
+		state: fields[0],
+		ppid:  int(num(1)),
+		pgid:  int(num(2)),
+		rss:   num(21) * int64(os.Getpagesize()) / 1024,
+		cpu:   time.Duration(num(11)+num(12)) * time.Second / clockTicks,
+		start: boot.Add(time.Duration(num(19)) * time.Second / clockTicks),
+	}
+	p.pid, _ = strconv.Atoi(pid)
+
+	if status, err := os.ReadFile(filepath.Join(dir, "status")); err == nil {
+		for _, line := range strings.Split(string(status), "\n") {
+			if rest, ok := strings.CutPrefix(line, "Uid:"); ok {
+				if f := strings.Fields(rest); len(f) > 0 {
+					p.uid = f[0]
+				}
+				break
+			}
+		}
+	}
+	p.user = userName(p.uid, names)
+
+	cmdline, _ := os.ReadFile(filepath.Join(dir, "cmdline"))
+	// управляющие символы в аргументах заменяются на ?, чтобы не ломать таблицу
+	p.cmd = strings.Map(func(r rune) rune {
+		if r == 0 {
+			return ' '
+		}
+		if unicode.IsControl(r) {
+			return '?'
+		}
+		return r
+	}, strings.TrimRight(string(cmdline), "\x00"))
+	if p.cmd == "" {
+		// у потоков ядра нет командной строки
+		p.cmd = "[" + comm + "]"
+	}
+	return p, nil
+}
+
+func userName(uid string, names map[string]string) string {
+	if name, ok := names[uid]; ok {
+		return name
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	names[uid] = name
+	return name
+}
+
+// bootTime возвращает время загрузки системы из строки btime в /proc/stat
+func bootTime() time.Time {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "btime "); ok {
+			if sec, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64); err == nil {
+				return time.Unix(sec, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// formatCPU выводит время процессора как MM:SS или HH:MM:SS
+func formatCPU(d time.Duration) string {
+	sec := int(d / time.Second)
+	if sec >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", sec/3600, sec/60%60, sec%60)
+	}
+	return fmt.Sprintf("%02d:%02d", sec/60, sec%60)
+}
+
+// formatStart выводит время запуска сегодняшних процессов и дату для остальных
+func formatStart(t, now time.Time) string {
+	if y, m, d := t.Date(); y == now.Year() && m == now.Month() && d == now.Day() {
+		return t.Format("15:04")
+	}
+	return t.Format("Jan02")
+}
@@ -0,0 +1,317 @@
+package builtins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// runTest реализует test и [. Истинное выражение даёт код 0, ложное — 1,
+// синтаксическая ошибка — 2
+// Относительные пути в проверках файлов отсчитываются от каталога dir
+func runTest(dir string, args []string) error {
+	name := args[0]
+	args = args[1:]
+	if name == "[" {
+		if len(args) == 0 || args[len(args)-1] != "]" {
+			return testError{fmt.Errorf("[: missing `]'")}
+		}
+		args = args[:len(args)-1]
+	}
+
+	t := &tester{args: args, dir: dir}
+	ok, err := t.eval()
+	if err != nil {
+		return testError{fmt.Errorf("%s: %v", name, err)}
+	}
+	if !ok {
+		return exitStatus(1)
+	}
+	return nil
+}
+
+// testError — ошибка разбора выражения test, завершающая команду с кодом 2
+type testError struct {
+	err error
+}
+
+func (e testError) Error() string {
+	return e.err.Error()
+}
+
+type tester struct {
+	args []string
+	pos  int
+	dir  string
+}
+
+// path возвращает путь к файлу операнда относительно текущего каталога шелла
+func (t *tester) path(arg string) string {
+	if arg == "" || filepath.IsAbs(arg) {
+		return arg
+	}
+	return filepath.Join(t.dir, arg)
+}
+
+// eval разбирает выражение целиком. До четырёх аргументов применяются правила
+// POSIX, учитывающие количество аргументов, иначе — грамматика с -o, -a, ! и скобками
+func (t *tester) eval() (bool, error) {
+	switch len(t.args) {
+	case 0:
+		return false, nil
+	case 1:
+		return t.args[0] != "", nil
+	case 2:
+		if t.args[0] == "!" {
+			return t.args[1] == "", nil
+		}
+		if isUnary(t.args[0]) {
+			return t.unary(t.args[0], t.args[1])
+		}
+		return false, fmt.Errorf("%s: unary operator expected", t.args[0])
+	case 3:
+		if isBinary(t.args[1]) {
+			return t.binary(t.args[0], t.args[1], t.args[2])
+		}
+		if t.args[0] == "!" {
+			t.args = t.args[1:]
+			ok, err := t.eval()
+			return !ok, err
+		}
+		if t.args[0] == "(" && t.args[2] == ")" {
+			return t.args[1] != "", nil
+		}
+	case 4:
+		if t.args[0] == "!" {
+			t.args = t.args[1:]
+			ok, err := t.eval()
+			return !ok, err
+		}
+		if t.args[0] == "(" && t.args[3] == ")" {
+			t.args = t.args[1:3]
+			return t.eval()
+		}
+	}
+
+	ok, err := t.or()
+	if err != nil {
+		return false, err
+	}
+	if t.pos < len(t.args) {
+		return false, fmt.Errorf("%s: unexpected argument", t.args[t.pos])
+	}
+	return ok, nil
+}
+
+func (t *tester) peek() (string, bool) {
+	if t.pos >= len(t.args) {
+		return "", false
+	}
+	return t.args[t.pos], true
+}
+
+func (t *tester) next() (string, error) {
+	arg, ok := t.peek()
+	if !ok {
+		return "", fmt.Errorf("argument expected")
+	}
+	t.pos++
+	return arg, nil
+}
+
+// or: and ('-o' and)*
+func (t *tester) or() (bool, error) {
+	ok, err := t.and()
+	for err == nil {
+		if arg, more := t.peek(); !more || arg != "-o" {
+			break
+		}
+		t.pos++
+		var right bool
+		right, err = t.and()
+		ok = ok || right
+	}
+	return ok, err
+}
+
+// and: not ('-a' not)*
+func (t *tester) and() (bool, error) {
+	ok, err := t.not()
+	for err == nil {
+		if arg, more := t.peek(); !more || arg != "-a" {
+			break
+		}
+		t.pos++
+		var right bool
+		right, err = t.not()
+		ok = ok && right
+	}
+	return ok, err
+}
+
+// not: '!' not | primary
+func (t *tester) not() (bool, error) {
+	if arg, ok := t.peek(); ok && arg == "!" {
+		t.pos++
+		ok, err := t.not()
+		return !ok, err
+	}
+	return t.primary()
+}
+
+// primary: '(' or ')' | unary_op arg | arg binary_op arg | arg
+func (t *tester) primary() (bool, error) {
+	arg, err := t.next()
+	if err != nil {
+		return false, err
+	}
+	if arg == "(" {
+		ok, err := t.or()
+		if err != nil {
+			return false, err
+		}
+		if closing, _ := t.next(); closing != ")" {
+			return false, fmt.Errorf("`)' expected")
+		}
+		return ok, nil
+	}
+	if op, ok := t.peek(); ok && isBinary(op) && t.pos+1 < len(t.args) {
+		t.pos++
+		right, _ := t.next()
+		return t.binary(arg, op, right)
+	}
+	if isUnary(arg) && t.pos < len(t.args) {
+		operand, _ := t.next()
+		return t.unary(arg, operand)
+	}
+	return arg != "", nil
+}
+
+func isUnary(op string) bool {
+	switch op {
+	case "-b", "-c", "-d", "-e", "-f", "-g", "-h", "-L", "-n", "-p", "-r", "-s", "-S", "-t", "-u", "-w", "-x", "-z":
+		return true
+	}
+	return false
+}
+
+func isBinary(op string) bool {
+	switch op {
+	case "=", "==", "!=", "<", ">", "-eq", "-ne", "-lt", "-le", "-gt", "-ge", "-nt", "-ot", "-ef":
+		return true
+	}
+	return false
+}
+
+func (t *tester) unary(op, arg string) (bool, error) {
+	switch op {
+	case "-n":
+		return arg != "", nil
+	case "-z":
+		return arg == "", nil
+	case "-t":
+		fd, err := strconv.Atoi(arg)
+		if err != nil {
+			return false, fmt.Errorf("%s: integer expression expected", arg)
+		}
+		return isTerminal(fd), nil
+	case "-r":
+		return syscall.Access(t.path(arg), 4) == nil, nil
+	case "-w":
+		return syscall.Access(t.path(arg), 2) == nil, nil
+	case "-x":
+		return syscall.Access(t.path(arg), 1) == nil, nil
+	}
+
+	stat := os.Stat
+	if op == "-h" || op == "-L" {
+		stat = os.Lstat
+	}
+	fi, err := stat(t.path(arg))
+	if err != nil {
+		return false, nil
+	}
+	mode := fi.Mode()
+	switch op {
+	case "-e":
+		return true, nil
+	case "-f":
+		return mode.IsRegular(), nil
+	case "-d":
+		return mode.IsDir(), nil
+	case "-h", "-L":
+		return mode&os.ModeSymlink != 0, nil
+	case "-p":
+		return mode&os.ModeNamedPipe != 0, nil
+	case "-S":
+		return mode&os.ModeSocket != 0, nil
+	case "-b":
+		return mode&os.ModeDevice != 0 && mode&os.ModeCharDevice == 0, nil
+	case "-c":
+		return mode&os.ModeCharDevice != 0, nil
+	case "-s":
+		return fi.Size() > 0, nil
+	case "-g":
+		return mode&os.ModeSetgid != 0, nil
+	case "-u":
+		return mode&os.ModeSetuid != 0, nil
+	}
+	return false, fmt.Errorf("%s: unary operator expected", op)
+}
+
+func (t *tester) binary(left, op, right string) (bool, error) {
+	switch op {
+	case "=", "==":
+		return left == right, nil
+	case "!=":
+		return left != right, nil
+	case "<":
+		return left < right, nil
+	case ">":
+		return left > right, nil
+	case "-nt", "-ot":
+		l, lerr := os.Stat(t.path(left))
+		r, rerr := os.Stat(t.path(right))
+		if op == "-nt" {
+			return lerr == nil && (rerr != nil || l.ModTime().After(r.ModTime())), nil
+		}
+		return rerr == nil && (lerr != nil || l.ModTime().Before(r.ModTime())), nil
+	case "-ef":
+		l, lerr := os.Stat(t.path(left))
+		r, rerr := os.Stat(t.path(right))
+		return lerr == nil && rerr == nil && os.SameFile(l, r), nil
+	}
+
+	a, err := strconv.ParseInt(left, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("%s: integer expression expected", left)
+	}
+	b, err := strconv.ParseInt(right, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("%s: integer expression expected", right)
+	}
+	switch op {
+	case "-eq":
+		return a == b, nil
+	case "-ne":
+		return a != b, nil
+	case "-lt":
+		return a < b, nil
+	case "-le":
+		return a <= b, nil
+	case "-gt":
+		return a > b, nil
+	default:
+		return a >= b, nil
+	}
+}
+
+// isTerminal сообщает, связан ли дескриптор с терминалом
+func isTerminal(fd int) bool {
+	var t syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TCGETS, uintptr(unsafe.Pointer(&t)))
+	return errno == 0
+}
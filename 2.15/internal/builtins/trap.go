@@ -0,0 +1,109 @@
+package builtins
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// runTrap реализует trap [-lp] [[action] condition ...]. Условие — сигнал,
+// EXIT (или 0) для выхода из шелла или ERR для команды с ненулевым кодом.
+// Действие - восстанавливает обработку по умолчанию, пустое действие
+// игнорирует сигнал. Без аргументов и с -p выводит установленные ловушки
+func runTrap(sh Shell, args []string, out, errOut io.Writer) error {
+	args = args[1:]
+	switch {
+	case len(args) > 0 && args[0] == "-l":
+		return listSignals(nil, out)
+	case len(args) == 0 || args[0] == "-p":
+		if len(args) > 0 {
+			args = args[1:]
+		}
+		return printTraps(sh.Traps(), args, out)
+	case args[0] == "--":
+		args = args[1:]
+		if len(args) == 0 {
+			return printTraps(sh.Traps(), nil, out)
+		}
+	}
+
+	action, conds := args[0], args[1:]
+	reset := action == "-"
+	// trap INT и trap 2 3 сбрасывают перечисленные условия
+	if len(conds) == 0 || isNumber(action) {
+		conds, reset = args, true
+	}
+	status := 0
+	for _, cond := range conds {
+		name, ok := trapName(cond)
+		if !ok {
+			fmt.Fprintf(errOut, "mini-sh: trap: %s: invalid signal specification\n", cond)
+			status = 1
+			continue
+		}
+		if reset {
+			sh.ResetTrap(name)
+		} else {
+			sh.SetTrap(name, action)
+		}
+	}
+	return statusError(status)
+}
+
+// trapName приводит условие ловушки к виду EXIT, ERR или имени сигнала без SIG
+func trapName(cond string) (string, bool) {
+	switch upper := strings.ToUpper(cond); upper {
+	case "EXIT", "SIGEXIT":
+		return "EXIT", true
+	case "ERR", "SIGERR":
+		return "ERR", true
+	}
+	sig, ok := ParseSignal(cond)
+	if !ok {
+		return "", false
+	}
+	if sig == 0 {
+		return "EXIT", true
+	}
+	return SignalName(sig), true
+}
+
+// printTraps выводит ловушки командами trap в порядке номеров сигналов:
+// EXIT первой, ERR последней
+func printTraps(traps map[string]string, conds []string, out io.Writer) error {
+	var names []string
+	if len(conds) == 0 {
+		for name := range traps {
+			names = append(names, name)
+		}
+	}
+	for _, cond := range conds {
+		name, ok := trapName(cond)
+		if !ok {
+			return fmt.Errorf("trap: %s: invalid signal specification", cond)
+		}
+		if _, set := traps[name]; set {
+			names = append(names, name)
+		}
+	}
+	order := func(name string) int {
+		switch name {
+		case "EXIT":
+			return 0
+		case "ERR":
+			return len(signalNames)
+		}
+		sig, _ := ParseSignal(name)
+		return int(sig)
+	}
+	slices.SortFunc(names, func(a, b string) int { return cmp.Compare(order(a), order(b)) })
+	for _, name := range names {
+		action := "'" + strings.ReplaceAll(traps[name], "'", `'\''`) + "'"
+		if _, err := fmt.Fprintf(out, "trap -- %s %s\n", action, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package builtins
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"wb-l2/internal/parser"
+)
+
+// runVars реализует export, unset, env, read и local
+func runVars(sh Shell, args []string, in io.Reader, out, errOut io.Writer) error {
+	switch args[0] {
+	case "export":
+		return runExport(sh, args[1:], out)
+	case "local":
+		return runLocal(sh, args[1:])
+	case "unset":
+		funcs := false
+		for _, name := range args[1:] {
+			switch {
+			case name == "-v":
+				funcs = false
+			case name == "-f":
+				funcs = true
+			case funcs:
+				sh.UnsetFunc(name)
+			case !parser.IsName(name):
+				return fmt.Errorf("unset: `%s': not a valid identifier", name)
+			default:
+				sh.UnsetVar(name)
+			}
+		}
+		return nil
+	case "env":
+		if len(args) == 1 {
+			for _, kv := range sh.Environ() {
+				fmt.Fprintln(out, kv)
+			}
+			return nil
+		}
+		// env с аргументами запускает команду в изменённом окружении,
+		// это делает внешняя утилита
+		path, err := sh.LookPath("env")
+		if err != nil {
+			return fmt.Errorf("env: %v", err)
+		}
+		cmd := exec.Command(path, args[1:]...)
+		cmd.Dir, cmd.Env = sh.Dir(), sh.Environ()
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = in, out, errOut
+		if err := cmd.Run(); err != nil {
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				return exitStatus(exitErr.ExitCode())
+			}
+			return fmt.Errorf("env: %v", err)
+		}
+		return nil
+	default:
+		return runRead(sh, args[1:], in, errOut)
+	}
+}
+
+// runExport реализует export [-n] [-p] [NAME[=value] ...]
+func runExport(sh Shell, args []string, out io.Writer) error {
+	exported := true
+	var names []string
+	for _, arg := range args {
+		switch arg {
+		case "-n":
+			exported = false
+		case "-p":
+		default:
+			names = append(names, arg)
+		}
+	}
+	if len(names) == 0 {
+		for _, kv := range sh.Environ() {
+			name, value, _ := strings.Cut(kv, "=")
+			fmt.Fprintf(out, "export %s=%s\n", name, parser.Quote(value))
+		}
+		return nil
+	}
+
+	for _, arg := range names {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !parser.IsName(name) {
+			return fmt.Errorf("export: `%s': not a valid identifier", arg)
+		}
+		if hasValue {
+			sh.SetVar(name, value)
+		}
+		sh.Export(name, exported)
+	}
+	return nil
+}
+
+// runLocal реализует local NAME[=value] ...: переменная без значения
+// внутри функции считается неустановленной
+func runLocal(sh Shell, args []string) error {
+	for _, arg := range args {
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !parser.IsName(name) {
+			return fmt.Errorf("local: `%s': not a valid identifier", arg)
+		}
+		if err := sh.Local(name); err != nil {
+			return err
+		}
+		if hasValue {
+			sh.SetVar(name, value)
+		} else {
+			sh.UnsetVar(name)
+		}
+	}
+	return nil
+}
+
+// runRead реализует read [-r] [-p prompt] [NAME ...]. Строка делится по IFS,
+// последняя переменная получает остаток строки. Без имён строка попадает в REPLY
+func runRead(sh Shell, args []string, in io.Reader, errOut io.Writer) error {
+	raw := false
+	var names []string
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-r":
+			raw = true
+		case "-p":
+			if i+1 >= len(args) {
+				return fmt.Errorf("read: -p: option requires an argument")
+			}
+			// как в bash, приглашение выводится, только когда ввод с терминала
+			if f, ok := in.(*os.File); ok && isTerminal(int(f.Fd())) {
+				fmt.Fprint(errOut, args[i+1])
+			}
+			i++
+		default:
+			if !parser.IsName(args[i]) {
+				return fmt.Errorf("read: `%s': not a valid identifier", args[i])
+			}
+			names = append(names, args[i])
+		}
+	}
+	if len(names) == 0 {
+		names = []string{"REPLY"}
+	}
+
+	line, eof, err := readLine(in, raw)
+	if err != nil {
+		return fmt.Errorf("read: %v", err)
+	}
+
+	ifs, ok := sh.Var("IFS")
+	if !ok {
+		ifs = " \t\n"
+	}
+	values := splitRead(line, ifs, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		sh.SetVar(name, value)
+	}
+	if eof {
+		return exitStatus(1)
+	}
+	return nil
+}
+
+// readLine читает строку по одному байту, чтобы не забрать из общего ввода
+// данные следующих команд. Без raw обратный слэш экранирует следующий символ
+func readLine(in io.Reader, raw bool) (string, bool, error) {
+	var (
+		b   strings.Builder
+		buf [1]byte
+	)
+	for {
+		n, err := in.Read(buf[:])
+		if n == 0 {
+			if err == io.EOF || err == nil {
+				return b.String(), true, nil
+			}
+			return "", false, err
+		}
+		c := buf[0]
+		if c == '\n' {
+			return b.String(), false, nil
+		}
+		if c == '\\' && !raw {
+			if n, _ := in.Read(buf[:]); n == 0 {
+				return b.String(), true, nil
+			}
+			if buf[0] != '\n' {
+				b.WriteByte(buf[0])
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+}
+
+// splitRead делит строку на не более чем n полей по символам IFS
+func splitRead(line, ifs string, n int) []string {
+	isSep := func(r rune) bool { return strings.ContainsRune(ifs, r) }
+	isSpace := func(r rune) bool { return isSep(r) && (r == ' ' || r == '\t' || r == '\n') }
+
+	line = strings.TrimFunc(line, isSpace)
+	var fields []string
+	for len(fields) < n-1 && line != "" {
+		i := strings.IndexFunc(line, isSep)
+		if i < 0 {
+			break
+		}
+		fields = append(fields, line[:i])
+		line = strings.TrimLeftFunc(line[i:], isSpace)
+		// один непробельный разделитель, окружённый пробелами IFS, считается одним
+		if line != "" && isSep(rune(line[0])) && !isSpace(rune(line[0])) {
+			line = strings.TrimLeftFunc(line[1:], isSpace)
+		}
+	}
+	return append(fields, line)
+}
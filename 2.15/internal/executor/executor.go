@@ -2,171 +2,345 @@ package executor
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"strings"
-	"time"
+	"sync"
+	"syscall"
 
 	"wb-l2/internal/builtins"
+	"wb-l2/internal/jobs"
 	"wb-l2/internal/model"
 )
 
-// RunPipeline запускает пайплайн команд. Ctrl+C должен отменять контекст upstream.
-func RunPipeline(ctx context.Context, cmds []model.Command) error {
-	if len(cmds) == 1 && builtins.IsBuiltin(cmds[0].Args[0]) && cmds[0].InFile == "" && cmds[0].OutFile == "" {
-		return builtins.Run(cmds[0].Args, os.Stdin, os.Stdout)
-	}
+// Stdio — стандартные потоки, которые получает пайплайн: первая стадия читает из In,
+// последняя пишет в Out. Extra — дескрипторы 3, 4, ..., открытые перенаправлениями
+// составной команды, например { cmd >&3; } 3>file
+type Stdio struct {
+	In    *os.File
+	Out   *os.File
+	Err   *os.File
+	Extra []*os.File
+}
 
-	var procs []*exec.Cmd
-	var readers []io.ReadCloser
-	var writers []io.WriteCloser
+// StdStreams возвращает стандартные потоки процесса шелла
+func StdStreams() Stdio {
+	return Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr}
+}
 
-	var in io.ReadCloser = os.Stdin
-	var err error
-	if cmds[0].InFile != "" {
-		in, err = os.Open(cmds[0].InFile)
-		if err != nil {
-			return err
-		}
-		readers = append(readers, in)
+// errOut возвращает поток для сообщений шелла; закрытый stderr их отбрасывает
+func (s Stdio) errOut() io.Writer {
+	if s.Err == nil {
+		return io.Discard
 	}
+	return s.Err
+}
 
-	for i, c := range cmds {
-		var cmd *exec.Cmd
-		if builtins.IsBuiltin(c.Args[0]) {
-			cmd = builtinAsCmd(c.Args)
-		} else {
-			cmd = exec.CommandContext(ctx, c.Args[0], c.Args[1:]...)
-		}
+// RunPipeline запускает пайплайн команд в отдельной группе процессов и возвращает
+// коды завершения всех стадий. Внешние команды запускаются процессами в текущем
+// каталоге и окружении шелла, встроенные команды и подоболочки со Script —
+// горутинами в копии состояния шелла; стадии соединяются каналами os.Pipe.
+// Сообщения шелла пишутся в stdio.Err, ошибки запуска — в stderr команды.
+// Фоновый пайплайн регистрируется в таблице заданий, и функция возвращается
+// сразу с нулевыми кодами; номер задания печатается только в интерактивном
+// режиме. Для переднего плана отмена ctx посылает процессам SIGINT.
+// Без управления заданиями процессы переднего плана остаются в группе шелла
+// и сами получают сигналы терминала, а фоновые — не получают: у них своя группа
+func RunPipeline(ctx context.Context, sh builtins.Shell, stdio Stdio, cmds []model.Command, text string, background bool) []int {
+	if len(cmds) == 1 && cmds[0].Script == "" && builtins.IsBuiltin(cmds[0].Args[0]) {
+		return []int{runBuiltin(sh, stdio, cmds[0])}
+	}
 
-		if i == 0 {
-			cmd.Stdin = in
+	table := sh.Jobs()
+	statuses := make([]int, len(cmds))
+	errOut := stdio.errOut()
+
+	// owned[i] — файлы стадии i: её концы каналов и файлы перенаправлений.
+	// Файлы процессов закрываются в родителе после запуска, файлы встроенной
+	// команды — горутиной по её завершении, чтобы соседние стадии получили EOF
+	owned := make([][]*os.File, len(cmds))
+	defer func() {
+		for _, files := range owned {
+			closeAll(files)
 		}
+	}()
+
+	noclobber := sh.Options()["noclobber"]
+	dir := sh.Dir()
+	procs := make([]*exec.Cmd, len(cmds))
+	stages := make([]func() int, len(cmds))
+	in := stdio.In
+	for i, c := range cmds {
+		fds := stdio.table()
+		fds[0] = in
 		if i < len(cmds)-1 {
-			pr, pw := io.Pipe()
-			cmd.Stdout = pw
-			readers = append(readers, pr)
-			writers = append(writers, pw)
-		} else {
-			if c.OutFile != "" {
-				var f *os.File
-				if c.AppendOut {
-					f, err = os.OpenFile(c.OutFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
-				} else {
-					f, err = os.OpenFile(c.OutFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
-				}
-				if err != nil {
-					return err
-				}
-				writers = append(writers, f)
-				cmd.Stdout = f
-			} else {
-				cmd.Stdout = os.Stdout
+			pr, pw, err := os.Pipe()
+			if err != nil {
+				fmt.Fprintf(errOut, "mini-sh: %v\n", err)
+				return failAll(statuses)
 			}
+			owned[i] = append(owned[i], pw)
+			owned[i+1] = append(owned[i+1], pr)
+			fds[1] = pw
+			in = pr
 		}
-		cmd.Stderr = os.Stderr
-		procs = append(procs, cmd)
-	}
+		fds, opened, err := applyRedirects(fds, c.Redirects, dir, noclobber)
+		if err != nil {
+			fmt.Fprintf(errOut, "mini-sh: %v\n", err)
+			statuses[i] = 1
+			continue
+		}
+		owned[i] = append(owned[i], opened...)
 
-	var prevReader io.ReadCloser
-	if cmds[0].InFile != "" {
-		prevReader = readers[0]
-	}
-	rIdx := 0
-	for i := range procs {
-		if i > 0 {
-			if prevReader == nil {
-				prevReader = readers[rIdx]
-			}
-			procs[i].Stdin = prevReader
-		}
-		if i < len(procs)-1 {
-			if cmds[0].InFile != "" {
-				rIdx = i
-				prevReader = readers[rIdx+1]
-			} else {
-				rIdx = i
-				prevReader = readers[rIdx]
-			}
+		// состояние шелла копируется сейчас, а не в горутине стадии:
+		// шелл продолжает работу, пока фоновая стадия выполняется
+		if c.Script != "" {
+			fork := sh.Fork()
+			stages[i] = func() int { return fork.Subshell(c.Script, fds) }
+			continue
+		}
+		if builtins.IsBuiltin(c.Args[0]) {
+			stages[i] = builtinStage(sh, c, fds)
+			continue
+		}
+		path, err := sh.LookPath(c.Args[0])
+		if err != nil {
+			statuses[i] = reportStartError(sh, fromTable(fds).errOut(), c.Args[0], err)
+			continue
 		}
+		procs[i] = newCmd(sh, c, path, fds)
 	}
 
+	pgid := 0
+	ownGroup := background || table.Interactive()
+	var pids []int
+	var started []int // индексы стадий, которые удалось запустить
 	for i, p := range procs {
+		if p == nil {
+			continue
+		}
+		p.SysProcAttr = &syscall.SysProcAttr{Setpgid: ownGroup, Pgid: pgid}
+		if pgid == 0 && !background && table.Interactive() {
+			// первый процесс сам забирает терминал до exec, чтобы не получить SIGTTIN
+			p.SysProcAttr.Foreground = true
+			p.SysProcAttr.Ctty = table.TTY()
+		}
 		if err := p.Start(); err != nil {
-			for _, r := range readers {
-				_ = r.Close()
-			}
-			for _, w := range writers {
-				_ = w.Close()
+			w := p.Stderr
+			if w == nil {
+				w = io.Discard
 			}
-			return err
+			statuses[i] = reportStartError(sh, w, cmds[i].Args[0], err)
+			continue
 		}
-		if i > 0 && i-1 < len(writers) {
-			_ = writers[i-1].Close()
+		if pgid == 0 {
+			pgid = p.Process.Pid
 		}
+		pids = append(pids, p.Process.Pid)
+		started = append(started, i)
+		// статусы собирает таблица заданий через wait4, поэтому
+		// дескриптор процесса в os.Process больше не нужен
+		_ = p.Process.Release()
 	}
 
-	done := make(chan error, len(procs))
-	for _, p := range procs {
-		go func(cmd *exec.Cmd) { done <- cmd.Wait() }(p)
+	// встроенные команды работают параллельно с процессами
+	var wg sync.WaitGroup
+	builtinStatuses := make([]int, len(cmds))
+	for i, run := range stages {
+		if run == nil {
+			continue
+		}
+		files := owned[i]
+		owned[i] = nil
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer closeAll(files)
+			builtinStatuses[i] = run()
+		}()
+	}
+	for i, files := range owned {
+		closeAll(files)
+		owned[i] = nil
+	}
+	collect := func() {
+		wg.Wait()
+		for i, run := range stages {
+			if run != nil {
+				statuses[i] = builtinStatuses[i]
+			}
+		}
 	}
 
-	timeout := time.NewTimer(0)
-	if !timeout.Stop() {
-		<-timeout.C
+	if len(pids) == 0 {
+		if !background {
+			collect()
+		}
+		return statuses
+	}
+
+	job := table.Add(pgid, pids, text)
+	if background {
+		table.SetCurrent(job)
+		table.SetLastBackground(job)
+		if table.Interactive() {
+			fmt.Fprintf(errOut, "[%d] %d\n", job.ID, pgid)
+		}
+		return statuses
 	}
 
-	var firstErr error
-	for i := 0; i < len(procs); i++ {
+	done := make(chan struct{})
+	go func() {
 		select {
-		case err := <-done:
-			if err != nil && firstErr == nil {
-				firstErr = err
-			}
 		case <-ctx.Done():
-			for _, p := range procs {
-				if p.Process != nil {
-					_ = p.Process.Kill()
-				}
+			// как терминал по Ctrl+C: процессы могут перехватить сигнал и завершиться сами
+			if ownGroup {
+				_ = syscall.Kill(-pgid, syscall.SIGINT)
+				return
+			}
+			for _, pid := range pids {
+				_ = syscall.Kill(pid, syscall.SIGINT)
 			}
-			firstErr = ctx.Err()
+		case <-done:
+		}
+	}()
+	table.Foreground(job, false)
+	close(done)
+	reportSignal(errOut, job)
+
+	for k, code := range job.PipeStatus() {
+		statuses[started[k]] = code
+	}
+	if job.State() == jobs.Stopped {
+		// встроенные стадии остановленного задания доработают после его продолжения
+		fmt.Fprintf(errOut, "\n%s\n", table.Format(job))
+		return statuses
+	}
+	collect()
+	return statuses
+}
+
+// Start запускает команду вне таблицы заданий, например команду подстановки
+// процесса, и возвращает канал, в который придёт её код завершения.
+// Процесс остаётся в группе шелла, подоболочка со Script выполняется горутиной.
+// Файлы owned закрываются, когда больше не нужны: после запуска процесса
+// или по завершении горутины
+func Start(sh builtins.Shell, c model.Command, fds, owned []*os.File) (<-chan int, error) {
+	done := make(chan int, 1)
+	if c.Script != "" {
+		// шелл продолжает работу, поэтому копия снимается до запуска горутины
+		fork := sh.Fork()
+		go func() {
+			defer closeAll(owned)
+			done <- fork.Subshell(c.Script, fds)
+		}()
+		return done, nil
+	}
+	defer closeAll(owned)
+	path, err := sh.LookPath(c.Args[0])
+	if err != nil {
+		return nil, err
+	}
+	cmd := newCmd(sh, c, path, fds)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	go func() {
+		_ = cmd.Wait()
+		ws := cmd.ProcessState.Sys().(syscall.WaitStatus)
+		if ws.Signaled() {
+			done <- 128 + int(ws.Signal())
+			return
 		}
+		done <- ws.ExitStatus()
+	}()
+	return done, nil
+}
+
+// newCmd готовит процесс внешней команды с таблицей дескрипторов fds
+// в текущем каталоге и окружении шелла
+func newCmd(sh builtins.Shell, c model.Command, path string, fds []*os.File) *exec.Cmd {
+	cmd := &exec.Cmd{Path: path, Args: c.Args, Dir: sh.Dir(), Env: append(sh.Environ(), c.Env...)}
+	// закрытые дескрипторы 0-2 заменяются на /dev/null
+	if fds[0] != nil {
+		cmd.Stdin = fds[0]
+	}
+	if fds[1] != nil {
+		cmd.Stdout = fds[1]
+	}
+	if fds[2] != nil {
+		cmd.Stderr = fds[2]
 	}
+	cmd.ExtraFiles = fds[3:]
+	return cmd
+}
 
-	for _, r := range readers {
-		_ = r.Close()
+// builtinStage возвращает функцию, выполняющую встроенную команду стадии пайплайна.
+// Как и в подоболочке, команда работает с копией состояния шелла,
+// а префиксы присваиваний экспортируются только в эту копию
+func builtinStage(sh builtins.Shell, c model.Command, fds []*os.File) func() int {
+	fork := sh.Fork()
+	for _, kv := range c.Env {
+		name, value, _ := strings.Cut(kv, "=")
+		fork.SetVar(name, value)
+		fork.Export(name, true)
 	}
-	for _, w := range writers {
-		_ = w.Close()
+	return func() int {
+		return builtins.Run(fork, c.Args, fds[0], fds[1], fds[2])
 	}
-	return firstErr
 }
 
-func builtinAsCmd(args []string) *exec.Cmd {
-	switch args[0] {
-	case "echo":
-		return exec.Command("/bin/sh", "-c", "echo "+shellQuote(strings.Join(args[1:], " ")))
-	case "pwd":
-		return exec.Command("/bin/sh", "-c", "pwd")
-	case "ps":
-		return exec.Command(os.Args[0], "__builtin_ps")
-	case "kill":
-		if len(args) < 2 {
-			return exec.Command("/bin/sh", "-c", "false")
-		}
-		return exec.Command(os.Args[0], "__builtin_kill", args[1])
-	default:
-		return exec.Command("/bin/sh", "-c", "false")
+// runBuiltin выполняет встроенную команду в процессе шелла, открыв файлы перенаправлений
+func runBuiltin(sh builtins.Shell, stdio Stdio, c model.Command) int {
+	fds, opened, err := applyRedirects(stdio.table(), c.Redirects, sh.Dir(), sh.Options()["noclobber"])
+	if err != nil {
+		fmt.Fprintf(stdio.errOut(), "mini-sh: %v\n", err)
+		return 1
 	}
+	defer closeAll(opened)
+	return builtins.Run(sh, c.Args, fds[0], fds[1], fds[2])
 }
 
-func shellQuote(s string) string {
-	if s == "" {
-		return "''"
+// reportSignal сообщает, что последний процесс задания убит сигналом.
+// О SIGINT и SIGPIPE не сообщается: их причина и так видна
+func reportSignal(w io.Writer, job *jobs.Job) {
+	p := job.Procs[len(job.Procs)-1]
+	if text := p.SignalText(); text != "" {
+		if sig := p.Status.Signal(); sig != syscall.SIGINT && sig != syscall.SIGPIPE {
+			fmt.Fprintln(w, text)
+		}
 	}
-	if strings.IndexFunc(s, func(r rune) bool { return r == ' ' || r == '\'' || r == '"' }) >= 0 {
-		return "'" + strings.ReplaceAll(s, "'", "'\\''") + "'"
+}
+
+// reportStartError печатает ошибку запуска и возвращает код как в POSIX-шеллах:
+// 127 — команда не найдена, 126 — найдена, но не может быть выполнена.
+// К ненайденной команде без пути добавляются похожие имена
+func reportStartError(sh builtins.Shell, w io.Writer, name string, err error) int {
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(w, "mini-sh: %s: command not found\n", name)
+		if similar := suggest(sh, name); len(similar) > 0 {
+			fmt.Fprintf(w, "mini-sh: similar commands: %s\n", strings.Join(similar, ", "))
+		}
+		return 127
+	}
+	fmt.Fprintf(w, "mini-sh: %s: %v\n", name, err)
+	return 126
+}
+
+// suggest подбирает похожие имена только для команд без пути
+func suggest(sh builtins.Shell, name string) []string {
+	if strings.Contains(name, "/") {
+		return nil
+	}
+	return builtins.Suggest(sh, name)
+}
+
+func failAll(statuses []int) []int {
+	for i := range statuses {
+		statuses[i] = 1
 	}
-	return s
+	return statuses
 }
@@ -0,0 +1,118 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"wb-l2/internal/model"
+)
+
+// Redirect применяет перенаправления составной команды, выполняемой в текущем шелле,
+// и возвращает новые потоки и функцию, закрывающую открытые файлы.
+// Относительные имена файлов отсчитываются от каталога dir
+func Redirect(stdio Stdio, redirects []model.Redirect, dir string, noclobber bool) (Stdio, func(), error) {
+	fds, opened, err := applyRedirects(stdio.table(), redirects, dir, noclobber)
+	if err != nil {
+		return stdio, nil, err
+	}
+	return fromTable(fds), func() { closeAll(opened) }, nil
+}
+
+// table возвращает таблицу дескрипторов: индекс — номер дескриптора, nil — закрытый
+func (s Stdio) table() []*os.File {
+	return append([]*os.File{s.In, s.Out, s.Err}, s.Extra...)
+}
+
+func fromTable(fds []*os.File) Stdio {
+	return Stdio{In: fds[0], Out: fds[1], Err: fds[2], Extra: fds[3:]}
+}
+
+// applyRedirects применяет перенаправления по порядку к копии таблицы fds.
+// Возвращает новую таблицу и открытые файлы, которые вызывающий закрывает
+// после запуска команды. При ошибке уже открытые файлы закрываются
+func applyRedirects(fds []*os.File, redirects []model.Redirect, dir string, noclobber bool) ([]*os.File, []*os.File, error) {
+	fds = append([]*os.File(nil), fds...)
+	var opened []*os.File
+	for _, r := range redirects {
+		for len(fds) <= r.Fd {
+			fds = append(fds, nil)
+		}
+		var (
+			f   *os.File
+			err error
+		)
+		target := r.Target
+		if target != "" && !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		switch r.Kind {
+		case model.RedirIn:
+			f, err = os.Open(target)
+		case model.RedirOut:
+			if noclobber {
+				if fi, statErr := os.Stat(target); statErr == nil && fi.Mode().IsRegular() {
+					err = fmt.Errorf("%s: cannot overwrite existing file", r.Target)
+					break
+				}
+			}
+			f, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		case model.RedirClobber:
+			f, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+		case model.RedirAppend:
+			f, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		case model.RedirReadWrite:
+			f, err = os.OpenFile(target, os.O_CREATE|os.O_RDWR, 0o644)
+		case model.RedirDup:
+			if r.DupFd >= len(fds) || fds[r.DupFd] == nil {
+				err = fmt.Errorf("%d: bad file descriptor", r.DupFd)
+				break
+			}
+			fds[r.Fd] = fds[r.DupFd]
+			continue
+		case model.RedirClose:
+			fds[r.Fd] = nil
+			continue
+		case model.RedirData:
+			f, err = dataFile(r.Data)
+		}
+		if err != nil {
+			closeAll(opened)
+			var pathErr *fs.PathError
+			if errors.As(err, &pathErr) {
+				err = fmt.Errorf("%s: %v", r.Target, pathErr.Err)
+			}
+			return nil, nil, err
+		}
+		opened = append(opened, f)
+		fds[r.Fd] = f
+	}
+	return fds, opened, nil
+}
+
+// dataFile возвращает файл с текстом here-document, открытый на чтение с начала.
+// Файл удаляется сразу и исчезает, когда закрыт последний дескриптор
+func dataFile(data string) (*os.File, error) {
+	f, err := os.CreateTemp("", "mini-sh-heredoc-")
+	if err != nil {
+		return nil, err
+	}
+	_ = os.Remove(f.Name())
+	if _, err := f.WriteString(data); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func closeAll(files []*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
@@ -0,0 +1,521 @@
+package expand
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxArithDepth ограничивает рекурсию, когда значение переменной само является выражением
+const maxArithDepth = 32
+
+// Arith вычисляет выражение $((...)) над 64-битными целыми. Перед вычислением
+// в выражении раскрываются параметры и подстановки команд. Поддерживаются
+// операторы C: арифметика, сравнения, битовые и логические операции,
+// тернарный оператор, присваивания, инкремент и декремент
+func Arith(expr string, env Env) (int64, error) {
+	text, err := Literal(expr, env)
+	if err != nil {
+		return 0, err
+	}
+	return evalArith(text, env, 0)
+}
+
+func evalArith(text string, env Env, depth int) (int64, error) {
+	if depth > maxArithDepth {
+		return 0, fmt.Errorf("%s: expression recursion level exceeded", text)
+	}
+	toks, err := lexArith(text)
+	if err != nil {
+		return 0, err
+	}
+	a := &arith{text: text, env: env, toks: toks, depth: depth}
+	if a.peek().kind == tokEnd {
+		return 0, nil
+	}
+	n, err := a.comma()
+	if err != nil {
+		return 0, err
+	}
+	if tok := a.peek(); tok.kind != tokEnd {
+		return 0, a.errorf("syntax error: invalid arithmetic operator (error token is \"%s\")", tok.text)
+	}
+	return n, nil
+}
+
+type arithKind int
+
+const (
+	tokEnd arithKind = iota
+	tokNum
+	tokName
+	tokOp
+)
+
+type arithToken struct {
+	kind arithKind
+	text string
+	num  int64
+}
+
+// arithOps упорядочены так, чтобы длинные операторы проверялись раньше коротких
+var arithOps = []string{
+	"<<=", ">>=",
+	"**", "<<", ">>", "<=", ">=", "==", "!=", "&&", "||", "++", "--",
+	"+=", "-=", "*=", "/=", "%=", "&=", "^=", "|=",
+	"+", "-", "*", "/", "%", "<", ">", "&", "|", "^", "!", "~", "?", ":", "=", "(", ")", ",",
+}
+
+func lexArith(s string) ([]arithToken, error) {
+	var toks []arithToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case '0' <= c && c <= '9':
+			j := i
+			// @ — цифра 62 в основаниях больше 36, только после base#
+			for j < len(s) && (isNameChar(s[j]) || s[j] == '#' || (s[j] == '@' && strings.Contains(s[i:j], "#"))) {
+				j++
+			}
+			n, err := parseArithNumber(s[i:j])
+			if err != nil {
+				return nil, err
+			}
+			toks = append(toks, arithToken{kind: tokNum, text: s[i:j], num: n})
+			i = j
+		case isNameStart(c):
+			j := i
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			toks = append(toks, arithToken{kind: tokName, text: s[i:j]})
+			i = j
+		default:
+			matched := false
+			for _, op := range arithOps {
+				if strings.HasPrefix(s[i:], op) {
+					toks = append(toks, arithToken{kind: tokOp, text: op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("%s: syntax error: invalid arithmetic operator (error token is \"%s\")", s, s[i:])
+			}
+		}
+	}
+	return append(toks, arithToken{kind: tokEnd}), nil
+}
+
+// parseArithNumber разбирает десятичное, восьмеричное (0NN), шестнадцатеричное (0xNN)
+// число или число в явном основании base#digits
+func parseArithNumber(s string) (int64, error) {
+	base := 10
+	digits := s
+	if b, d, ok := strings.Cut(s, "#"); ok {
+		n, err := strconv.Atoi(b)
+		if err != nil || n < 2 || n > 64 {
+			return 0, fmt.Errorf("%s: invalid arithmetic base", s)
+		}
+		base, digits = n, d
+	} else if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		base, digits = 16, s[2:]
+	} else if len(s) > 1 && s[0] == '0' {
+		base, digits = 8, s[1:]
+	}
+	if base <= 36 {
+		n, err := strconv.ParseInt(digits, base, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: value too great for base", s)
+		}
+		return n, nil
+	}
+
+	// основания больше 36: 0-9, a-z, A-Z, @, _
+	var n int64
+	for _, c := range digits {
+		var d int
+		switch {
+		case '0' <= c && c <= '9':
+			d = int(c - '0')
+		case 'a' <= c && c <= 'z':
+			d = int(c-'a') + 10
+		case 'A' <= c && c <= 'Z':
+			d = int(c-'A') + 36
+		case c == '@':
+			d = 62
+		case c == '_':
+			d = 63
+		}
+		if d >= base {
+			return 0, fmt.Errorf("%s: value too great for base", s)
+		}
+		n = n*int64(base) + int64(d)
+	}
+	return n, nil
+}
+
+type arith struct {
+	text  string
+	env   Env
+	toks  []arithToken
+	pos   int
+	depth int
+	// skip > 0 подавляет побочные эффекты и ошибки деления в невычисляемых
+	// ветках: правой части && и || и невыбранной ветке ?:
+	skip int
+}
+
+func (a *arith) peek() arithToken {
+	return a.toks[a.pos]
+}
+
+func (a *arith) next() arithToken {
+	tok := a.toks[a.pos]
+	if tok.kind != tokEnd {
+		a.pos++
+	}
+	return tok
+}
+
+func (a *arith) isOp(op string) bool {
+	tok := a.peek()
+	return tok.kind == tokOp && tok.text == op
+}
+
+func (a *arith) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s: "+format, append([]any{a.text}, args...)...)
+}
+
+// comma: assign (',' assign)*
+func (a *arith) comma() (int64, error) {
+	n, err := a.assign()
+	for err == nil && a.isOp(",") {
+		a.next()
+		n, err = a.assign()
+	}
+	return n, err
+}
+
+var assignOps = map[string]string{
+	"=": "", "+=": "+", "-=": "-", "*=": "*", "/=": "/", "%=": "%",
+	"<<=": "<<", ">>=": ">>", "&=": "&", "^=": "^", "|=": "|",
+}
+
+// assign: name assign_op assign | ternary
+func (a *arith) assign() (int64, error) {
+	tok := a.peek()
+	if tok.kind == tokName && a.toks[a.pos+1].kind == tokOp {
+		if op, ok := assignOps[a.toks[a.pos+1].text]; ok {
+			a.pos += 2
+			rhs, err := a.assign()
+			if err != nil {
+				return 0, err
+			}
+			if op != "" {
+				cur, err := a.variable(tok.text)
+				if err != nil {
+					return 0, err
+				}
+				if rhs, err = a.binop(op, cur, rhs); err != nil {
+					return 0, err
+				}
+			}
+			a.set(tok.text, rhs)
+			return rhs, nil
+		}
+	}
+	return a.ternary()
+}
+
+// ternary: binary(0) ['?' assign ':' assign]
+func (a *arith) ternary() (int64, error) {
+	cond, err := a.binary(0)
+	if err != nil || !a.isOp("?") {
+		return cond, err
+	}
+	a.next()
+
+	if cond == 0 {
+		a.skip++
+	}
+	then, err := a.assign()
+	if cond == 0 {
+		a.skip--
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !a.isOp(":") {
+		return 0, a.errorf("`:' expected for conditional expression")
+	}
+	a.next()
+
+	if cond != 0 {
+		a.skip++
+	}
+	otherwise, err := a.assign()
+	if cond != 0 {
+		a.skip--
+	}
+	if err != nil {
+		return 0, err
+	}
+	if cond != 0 {
+		return then, nil
+	}
+	return otherwise, nil
+}
+
+// levels — бинарные операторы по возрастанию приоритета
+var levels = [][]string{
+	{"||"},
+	{"&&"},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// binary разбирает левоассоциативные операторы уровня level и выше
+func (a *arith) binary(level int) (int64, error) {
+	if level == len(levels) {
+		return a.power()
+	}
+	left, err := a.binary(level + 1)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		tok := a.peek()
+		if tok.kind != tokOp || !contains(levels[level], tok.text) {
+			return left, nil
+		}
+		a.next()
+
+		// правая часть && и || вычисляется только при необходимости
+		short := (tok.text == "&&" && left == 0) || (tok.text == "||" && left != 0)
+		if short {
+			a.skip++
+		}
+		right, err := a.binary(level + 1)
+		if short {
+			a.skip--
+		}
+		if err != nil {
+			return 0, err
+		}
+		if left, err = a.binop(tok.text, left, right); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// power: unary ['**' power]
+func (a *arith) power() (int64, error) {
+	base, err := a.unary()
+	if err != nil || !a.isOp("**") {
+		return base, err
+	}
+	a.next()
+	exp, err := a.power()
+	if err != nil {
+		return 0, err
+	}
+	return a.binop("**", base, exp)
+}
+
+// unary: ('+' | '-' | '!' | '~') unary | ('++' | '--') name | postfix
+func (a *arith) unary() (int64, error) {
+	tok := a.peek()
+	if tok.kind == tokOp {
+		switch tok.text {
+		case "+", "-", "!", "~":
+			a.next()
+			n, err := a.unary()
+			if err != nil {
+				return 0, err
+			}
+			switch tok.text {
+			case "-":
+				return -n, nil
+			case "!":
+				return boolInt(n == 0), nil
+			case "~":
+				return ^n, nil
+			}
+			return n, nil
+		case "++", "--":
+			a.next()
+			name := a.next()
+			if name.kind != tokName {
+				return 0, a.errorf("syntax error: operand expected (error token is \"%s\")", name.text)
+			}
+			n, err := a.variable(name.text)
+			if err != nil {
+				return 0, err
+			}
+			if tok.text == "++" {
+				n++
+			} else {
+				n--
+			}
+			a.set(name.text, n)
+			return n, nil
+		}
+	}
+	return a.postfix()
+}
+
+// postfix: name ('++' | '--') | primary
+func (a *arith) postfix() (int64, error) {
+	tok := a.peek()
+	if tok.kind == tokName && a.toks[a.pos+1].kind == tokOp {
+		if op := a.toks[a.pos+1].text; op == "++" || op == "--" {
+			a.pos += 2
+			n, err := a.variable(tok.text)
+			if err != nil {
+				return 0, err
+			}
+			if op == "++" {
+				a.set(tok.text, n+1)
+			} else {
+				a.set(tok.text, n-1)
+			}
+			return n, nil
+		}
+	}
+	return a.primary()
+}
+
+// primary: number | name | '(' comma ')'
+func (a *arith) primary() (int64, error) {
+	tok := a.next()
+	switch tok.kind {
+	case tokNum:
+		return tok.num, nil
+	case tokName:
+		return a.variable(tok.text)
+	case tokOp:
+		if tok.text == "(" {
+			n, err := a.comma()
+			if err != nil {
+				return 0, err
+			}
+			if !a.isOp(")") {
+				return 0, a.errorf("missing `)'")
+			}
+			a.next()
+			return n, nil
+		}
+	}
+	text := tok.text
+	if tok.kind == tokEnd {
+		text = ""
+	}
+	return 0, a.errorf("syntax error: operand expected (error token is \"%s\")", text)
+}
+
+// variable возвращает значение переменной. Пустая или неустановленная переменная
+// равна 0, нечисловое значение вычисляется как выражение
+func (a *arith) variable(name string) (int64, error) {
+	v, _ := a.env.Var(name)
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, nil
+	}
+	if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return n, nil
+	}
+	return evalArith(v, a.env, a.depth+1)
+}
+
+func (a *arith) set(name string, n int64) {
+	if a.skip == 0 {
+		a.env.SetVar(name, strconv.FormatInt(n, 10))
+	}
+}
+
+func (a *arith) binop(op string, l, r int64) (int64, error) {
+	switch op {
+	case "||":
+		return boolInt(l != 0 || r != 0), nil
+	case "&&":
+		return boolInt(l != 0 && r != 0), nil
+	case "|":
+		return l | r, nil
+	case "^":
+		return l ^ r, nil
+	case "&":
+		return l & r, nil
+	case "==":
+		return boolInt(l == r), nil
+	case "!=":
+		return boolInt(l != r), nil
+	case "<":
+		return boolInt(l < r), nil
+	case "<=":
+		return boolInt(l <= r), nil
+	case ">":
+		return boolInt(l > r), nil
+	case ">=":
+		return boolInt(l >= r), nil
+	case "<<":
+		return l << uint64(r&63), nil
+	case ">>":
+		return l >> uint64(r&63), nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/", "%":
+		if r == 0 {
+			if a.skip > 0 {
+				return 0, nil
+			}
+			return 0, a.errorf("division by 0")
+		}
+		if op == "/" {
+			return l / r, nil
+		}
+		return l % r, nil
+	case "**":
+		if r < 0 {
+			if a.skip > 0 {
+				return 0, nil
+			}
+			return 0, a.errorf("exponent less than 0")
+		}
+		n := int64(1)
+		for ; r > 0; r-- {
+			n *= l
+		}
+		return n, nil
+	}
+	return 0, a.errorf("unknown operator %s", op)
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func contains(ops []string, op string) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
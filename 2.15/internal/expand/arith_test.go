@@ -0,0 +1,227 @@
+package expand
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testEnv — окружение раскрытия без шелла: переменные из карты,
+// подстановки команд и процессов не поддерживаются
+type testEnv struct {
+	vars map[string]string
+	args []string
+	dir  string
+}
+
+func newTestEnv(vars map[string]string) *testEnv {
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+	return &testEnv{vars: vars}
+}
+
+func (e *testEnv) Var(name string) (string, bool) {
+	v, ok := e.vars[name]
+	return v, ok
+}
+
+func (e *testEnv) SetVar(name, value string) {
+	e.vars[name] = value
+}
+
+func (e *testEnv) Args() []string {
+	return e.args
+}
+
+func (e *testEnv) Subst(script string) (string, error) {
+	return "", errors.New("command substitution is not supported in tests")
+}
+
+func (e *testEnv) ProcSubst(script string, output bool) (string, error) {
+	return "", errors.New("process substitution is not supported in tests")
+}
+
+func (e *testEnv) Dir() string {
+	return e.dir
+}
+
+func TestArith(t *testing.T) {
+	tests := []struct {
+		expr string
+		want int64
+	}{
+		{"", 0},
+		{"  42  ", 42},
+
+		// Приоритет и ассоциативность
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"100 / 10 / 5", 2},
+		{"7 / 2", 3},
+		{"-7 / 2", -3},
+		{"-3 % 2", -1},
+		{"2 + 3 == 5", 1},
+		{"1 < 2 == 1", 1},
+		{"1 | 2 ^ 3 & 1", 3},
+		{"6 & 3 | 8", 10},
+		{"1 < 2 && 2 < 1 || 3", 1},
+		{"!0 + 1", 2},
+		{"!5", 0},
+		{"~0", -1},
+		{"- -3", 3},
+		{"+4", 4},
+		{"1 << 3 + 1", 16},
+
+		// Степень: правоассоциативна, унарный минус связывает сильнее
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", 4},
+		{"3 * 2 ** 2", 12},
+		{"5 ** 0", 1},
+
+		// Сдвиги
+		{"1 << 4", 16},
+		{"256 >> 4", 16},
+		{"-16 >> 2", -4},
+		{"1 << 64", 1},
+
+		// Тернарный оператор и запятая
+		{"1 ? 2 : 3", 2},
+		{"0 ? 2 : 3", 3},
+		{"0 ? 1 : 0 ? 2 : 3", 3},
+		{"1 ? 0 ? 4 : 5 : 6", 5},
+		{"1, 2, 3", 3},
+		{"(1, 2) + 1", 3},
+
+		// Невычисляемые ветки не дают ошибок деления
+		{"0 ? 1 / 0 : 7", 7},
+		{"1 ? 7 : 1 / 0", 7},
+		{"0 && 1 / 0", 0},
+		{"1 || 1 / 0", 1},
+		{"0 && 2 ** -1", 0},
+
+		// Основания
+		{"0", 0},
+		{"0x1f", 31},
+		{"0X10", 16},
+		{"010", 8},
+		{"2#1010", 10},
+		{"8#17", 15},
+		{"16#ff", 255},
+		{"36#z", 35},
+		{"64#A", 36},
+		{"64#@", 62},
+		{"64#_", 63},
+		{"64#10", 64},
+
+		// Переполнение переносится, как в 64-битной арифметике C
+		{"9223372036854775807 + 1", math.MinInt64},
+		{"-9223372036854775807 - 2", math.MaxInt64},
+		{"2 ** 63", math.MinInt64},
+		{"2 ** 64", 0},
+		{"4611686018427387904 * 4", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := Arith(tt.expr, newTestEnv(nil))
+			if err != nil {
+				t.Fatalf("Arith(%q) error = %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Arith(%q) = %d, want %d", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArithVariables(t *testing.T) {
+	tests := []struct {
+		expr string
+		want int64
+		// vars — значения переменных после вычисления
+		vars map[string]string
+	}{
+		{"x", 5, nil},
+		{"$x + 1", 6, nil},
+		{"unset + 1", 1, nil},
+		{"empty + 1", 1, nil},
+		{"expr + 1", 11, nil},
+		{"x = 7", 7, map[string]string{"x": "7"}},
+		{"y = x = 2", 2, map[string]string{"x": "2", "y": "2"}},
+		{"x += 3", 8, map[string]string{"x": "8"}},
+		{"x -= 2", 3, map[string]string{"x": "3"}},
+		{"x *= 2", 10, map[string]string{"x": "10"}},
+		{"x /= 2", 2, map[string]string{"x": "2"}},
+		{"x %= 3", 2, map[string]string{"x": "2"}},
+		{"x <<= 2", 20, map[string]string{"x": "20"}},
+		{"x >>= 1", 2, map[string]string{"x": "2"}},
+		{"x &= 4", 4, map[string]string{"x": "4"}},
+		{"x |= 2", 7, map[string]string{"x": "7"}},
+		{"x ^= 1", 4, map[string]string{"x": "4"}},
+		{"x += 2 * 3", 11, map[string]string{"x": "11"}},
+		{"x++", 5, map[string]string{"x": "6"}},
+		{"x--", 5, map[string]string{"x": "4"}},
+		{"++x", 6, map[string]string{"x": "6"}},
+		{"--x", 4, map[string]string{"x": "4"}},
+		{"x++ + x", 11, map[string]string{"x": "6"}},
+		{"n = 1, n += 1, n * 10", 20, map[string]string{"n": "2"}},
+		{"0 && (x = 9)", 0, map[string]string{"x": "5"}},
+		{"1 ? x : (x = 9)", 5, map[string]string{"x": "5"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			env := newTestEnv(map[string]string{"x": "5", "empty": "", "expr": "x * 2"})
+			got, err := Arith(tt.expr, env)
+			if err != nil {
+				t.Fatalf("Arith(%q) error = %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Arith(%q) = %d, want %d", tt.expr, got, tt.want)
+			}
+			for name, want := range tt.vars {
+				if v := env.vars[name]; v != want {
+					t.Errorf("Arith(%q): %s = %q, want %q", tt.expr, name, v, want)
+				}
+			}
+		})
+	}
+}
+
+func TestArithErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"1 / 0", "division by 0"},
+		{"5 % 0", "division by 0"},
+		{"x /= 0", "division by 0"},
+		{"2 ** -1", "exponent less than 0"},
+		{"1 +", "operand expected"},
+		{"* 2", "operand expected"},
+		{"(1 + 2", "missing `)'"},
+		{"1 ? 2", "`:' expected"},
+		{"1 @ 2", "invalid arithmetic operator"},
+		{"1 2", "invalid arithmetic operator"},
+		{"++1", "operand expected"},
+		{"08", "value too great for base"},
+		{"2#102", "value too great for base"},
+		{"1#1", "invalid arithmetic base"},
+		{"65#1", "invalid arithmetic base"},
+		{"self", "recursion level exceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			env := newTestEnv(map[string]string{"x": "5", "self": "self + 1"})
+			_, err := Arith(tt.expr, env)
+			if err == nil {
+				t.Fatalf("Arith(%q) error = nil, want %q", tt.expr, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Arith(%q) error = %v, want %q", tt.expr, err, tt.want)
+			}
+		})
+	}
+}
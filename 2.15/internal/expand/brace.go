@@ -0,0 +1,169 @@
+package expand
+
+import (
+	"strconv"
+	"strings"
+)
+
+// braces раскрывает фигурные скобки в исходном слове до остальных подстановок:
+// a{b,c}d даёт abd и acd, {1..5} и {a..e} — последовательности, {1..10..3} —
+// последовательность с шагом. Скобки в кавычках, в ${...} и без запятой или ..
+// остаются как есть
+func braces(word string) []string {
+	for i := 0; i < len(word); i++ {
+		switch word[i] {
+		case '\\':
+			i++
+		case '\'':
+			if end := strings.IndexByte(word[i+1:], '\''); end >= 0 {
+				i += end + 1
+			}
+		case '"':
+			i = closingQuote(word, i)
+		case '`':
+			i = closingBackquote(word, i)
+		case '$':
+			if i+1 < len(word) && (word[i+1] == '{' || word[i+1] == '(') {
+				i = matching(word, i+1)
+			}
+		case '{':
+			alts, end, ok := braceAlternatives(word, i)
+			if !ok {
+				continue
+			}
+			var words []string
+			for _, alt := range alts {
+				words = append(words, braces(word[:i]+alt+word[end+1:])...)
+			}
+			return words
+		}
+	}
+	return []string{word}
+}
+
+// braceAlternatives разбирает {...} в позиции i и возвращает варианты
+// и индекс закрывающей скобки
+func braceAlternatives(word string, i int) ([]string, int, bool) {
+	depth := 0
+	start := i + 1
+	var alts []string
+	for j := i; j < len(word); j++ {
+		switch word[j] {
+		case '\\':
+			j++
+		case '\'':
+			if end := strings.IndexByte(word[j+1:], '\''); end >= 0 {
+				j += end + 1
+			}
+		case '"':
+			j = closingQuote(word, j)
+		case '`':
+			j = closingBackquote(word, j)
+		case '$':
+			if j+1 < len(word) && (word[j+1] == '{' || word[j+1] == '(') {
+				j = matching(word, j+1)
+			}
+		case '{':
+			depth++
+		case ',':
+			if depth == 1 {
+				alts = append(alts, word[start:j])
+				start = j + 1
+			}
+		case '}':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if alts != nil {
+				return append(alts, word[start:j]), j, true
+			}
+			if seq, ok := sequence(word[i+1 : j]); ok {
+				return seq, j, true
+			}
+			return nil, 0, false
+		}
+	}
+	return nil, 0, false
+}
+
+// sequence раскрывает диапазон x..y[..step] из чисел или одиночных букв.
+// Числа с ведущими нулями дополняются нулями до общей ширины
+func sequence(body string) ([]string, bool) {
+	parts := strings.Split(body, "..")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, false
+	}
+	step := 1
+	if len(parts) == 3 {
+		n, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return nil, false
+		}
+		step = max(n, -n, 1)
+	}
+
+	lo, errLo := strconv.Atoi(parts[0])
+	hi, errHi := strconv.Atoi(parts[1])
+	if errLo == nil && errHi == nil {
+		width := 0
+		if zeroPadded(parts[0]) || zeroPadded(parts[1]) {
+			width = max(len(parts[0]), len(parts[1]))
+		}
+		var seq []string
+		for _, n := range steps(lo, hi, step) {
+			s := strconv.Itoa(n)
+			if n < 0 {
+				s = strconv.Itoa(-n)
+			}
+			for len(s) < width-boolLen(n < 0) {
+				s = "0" + s
+			}
+			if n < 0 {
+				s = "-" + s
+			}
+			seq = append(seq, s)
+		}
+		return seq, true
+	}
+
+	if len(parts[0]) == 1 && len(parts[1]) == 1 && isLetter(parts[0][0]) && isLetter(parts[1][0]) {
+		var seq []string
+		for _, c := range steps(int(parts[0][0]), int(parts[1][0]), step) {
+			seq = append(seq, string(rune(c)))
+		}
+		return seq, true
+	}
+	return nil, false
+}
+
+// steps возвращает значения от lo до hi включительно в нужном направлении
+func steps(lo, hi, step int) []int {
+	var out []int
+	if lo <= hi {
+		for n := lo; n <= hi; n += step {
+			out = append(out, n)
+		}
+	} else {
+		for n := lo; n >= hi; n -= step {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func zeroPadded(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	return len(s) > 1 && s[0] == '0'
+}
+
+func boolLen(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func isLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
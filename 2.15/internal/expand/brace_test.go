@@ -0,0 +1,107 @@
+package expand
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBraces(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		// Перечисления
+		{"a{b,c}d", []string{"abd", "acd"}},
+		{"{a,b}{1,2}", []string{"a1", "a2", "b1", "b2"}},
+		{"{a,}b", []string{"ab", "b"}},
+		{"x{,y}", []string{"x", "xy"}},
+
+		// Вложенные скобки
+		{"{x,y{1,2}}", []string{"x", "y1", "y2"}},
+		{"{a,{b,c}}", []string{"a", "b", "c"}},
+		{"{a,b{c,d{e,f}}}", []string{"a", "bc", "bde", "bdf"}},
+
+		// Последовательности
+		{"{1..3}", []string{"1", "2", "3"}},
+		{"{3..1}", []string{"3", "2", "1"}},
+		{"{-2..2}", []string{"-2", "-1", "0", "1", "2"}},
+		{"{1..10..4}", []string{"1", "5", "9"}},
+		{"{5..1..2}", []string{"5", "3", "1"}},
+		{"{01..10..3}", []string{"01", "04", "07", "10"}},
+		{"{a..e..2}", []string{"a", "c", "e"}},
+		{"{1..3}x{a,b}", []string{"1xa", "1xb", "2xa", "2xb", "3xa", "3xb"}},
+
+		// Кавычки и экранирование: внутри варианта кавычки сохраняются,
+		// скобки в кавычках и после \ не раскрываются
+		{`{"a b",c}`, []string{`"a b"`, "c"}},
+		{`{'x,y',z}`, []string{`'x,y'`, "z"}},
+		{`a{b,c\,d}`, []string{"ab", `ac\,d`}},
+		{`"{a,b}"`, []string{`"{a,b}"`}},
+		{`'{a,b}'`, []string{`'{a,b}'`}},
+		{`\{a,b}`, []string{`\{a,b}`}},
+		{`"x"{1,2}`, []string{`"x"1`, `"x"2`}},
+
+		// Подстановки не раскрываются как скобки
+		{"${x}{1,2}", []string{"${x}1", "${x}2"}},
+		{"${x,y}", []string{"${x,y}"}},
+		{"$(echo {a,b})", []string{"$(echo {a,b})"}},
+
+		// Не перечисление и не последовательность — остаётся как есть
+		{"{single}", []string{"{single}"}},
+		{"{}", []string{"{}"}},
+		{"{a,b", []string{"{a,b"}},
+		{"a,b}", []string{"a,b}"}},
+		{"{1..a}", []string{"{1..a}"}},
+		{"{1..}", []string{"{1..}"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := braces(tt.word); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("braces(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFieldsGlob(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", ".hidden.go", "c.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env := newTestEnv(map[string]string{"p": "*.go", "none": "*.rs"})
+	env.dir = dir
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"*.go", []string{"a.go", "b.go"}},
+		{"{a,c}.*", []string{"a.go", "c.txt"}},
+		{"$p", []string{"a.go", "b.go"}},
+
+		// Шаблон без совпадений остаётся как есть
+		{"*.rs", []string{"*.rs"}},
+		{"$none", []string{"*.rs"}},
+		{"{x,b}.go", []string{"x.go", "b.go"}},
+		{"[!ab].go", []string{"[!ab].go"}},
+
+		// Метасимволы в кавычках и после \ не раскрываются
+		{`"*.go"`, []string{"*.go"}},
+		{`\*.go`, []string{"*.go"}},
+		{`"$p"`, []string{"*.go"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			got, err := Fields(tt.word, env)
+			if err != nil {
+				t.Fatalf("Fields(%q) error = %v", tt.word, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields(%q) = %q, want %q", tt.word, got, tt.want)
+			}
+		})
+	}
+}
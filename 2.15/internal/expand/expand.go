@@ -0,0 +1,683 @@
+// Package expand раскрывает слова шелла: фигурные скобки и тильду, подставляет
+// параметры, вывод команд, подстановки процессов и арифметику, разбивает
+// результат на поля по IFS, раскрывает шаблоны имён файлов и снимает кавычки.
+// Слова приходят из парсера в исходном виде, поэтому кавычки учитываются
+// при раскрытии, а не до него
+package expand
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"wb-l2/internal/pattern"
+)
+
+// Env — параметры, доступные при раскрытии
+type Env interface {
+	// Var возвращает значение переменной или специального параметра ($?, $#, $1, ...)
+	Var(name string) (string, bool)
+	// SetVar присваивает переменную, используется в ${VAR:=word}
+	SetVar(name, value string)
+	// Args возвращает позиционные параметры для $@ и $*
+	Args() []string
+	// Subst выполняет скрипт подстановки команды и возвращает его вывод
+	Subst(script string) (string, error)
+	// ProcSubst запускает скрипт подстановки процесса и возвращает путь к каналу,
+	// из которого читается его вывод или, при output, в который пишется его ввод
+	ProcSubst(script string, output bool) (string, error)
+	// Dir возвращает текущий каталог, в котором раскрываются шаблоны имён файлов
+	Dir() string
+}
+
+// Fields раскрывает слово аргумента команды: фигурные скобки дают несколько слов,
+// результат подстановок вне кавычек разбивается на поля по IFS, пустые поля
+// без кавычек отбрасываются, а поля с метасимволами вне кавычек заменяются
+// совпавшими именами файлов. Шаблон без совпадений остаётся как есть
+func Fields(word string, env Env) ([]string, error) {
+	var fields []string
+	for _, w := range braces(word) {
+		items, err := expandWord(w, env, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range split(items, ifs(env)) {
+			if pattern.HasMeta(f.pattern) {
+				if matches := pattern.Glob(f.pattern, env.Dir()); len(matches) > 0 {
+					fields = append(fields, matches...)
+					continue
+				}
+			}
+			fields = append(fields, f.text)
+		}
+	}
+	return fields, nil
+}
+
+// Literal раскрывает слово без разбиения на поля: значение присваивания,
+// имя файла перенаправления, слово case
+func Literal(word string, env Env) (string, error) {
+	return literal(word, env, false)
+}
+
+// Assignment раскрывает значение присваивания: как Literal, но тильда
+// раскрывается также после каждого двоеточия, как в PATH=~/bin:~/go/bin
+func Assignment(word string, env Env) (string, error) {
+	return literal(word, env, true)
+}
+
+func literal(word string, env Env, assign bool) (string, error) {
+	items, err := expandWord(word, env, assign)
+	if err != nil {
+		return "", err
+	}
+	return join(items), nil
+}
+
+// Heredoc раскрывает тело here-document с ограничителем без кавычек:
+// подставляются параметры, команды и арифметика, а обратный слэш
+// экранирует только $, `, \ и перевод строки
+func Heredoc(body string, env Env) (string, error) {
+	e := &expander{env: env, heredoc: true}
+	if err := e.expand(body, true); err != nil {
+		return "", err
+	}
+	return join(e.items), nil
+}
+
+// join склеивает куски в одну строку, поля "$@" разделяются пробелом
+func join(items []item) string {
+	var b strings.Builder
+	for _, it := range items {
+		if it.brk {
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteString(it.text)
+	}
+	return b.String()
+}
+
+// Pattern раскрывает слово в шаблон: части в кавычках экранируются и
+// совпадают буквально, остальное сохраняет смысл *, ? и [...]
+func Pattern(word string, env Env) (string, error) {
+	items, err := expandWord(word, env, false)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, it := range items {
+		switch {
+		case it.brk:
+			b.WriteByte(' ')
+		case it.quoted:
+			b.WriteString(pattern.Escape(it.text))
+		default:
+			b.WriteString(it.text)
+		}
+	}
+	return b.String(), nil
+}
+
+// item — кусок раскрытого слова. split выставляется для результатов
+// подстановок вне кавычек, brk разделяет поля "$@"
+type item struct {
+	text   string
+	quoted bool
+	split  bool
+	brk    bool
+}
+
+type field struct {
+	text    string
+	pattern string
+}
+
+func ifs(env Env) string {
+	if v, ok := env.Var("IFS"); ok {
+		return v
+	}
+	return " \t\n"
+}
+
+// split собирает поля из кусков, разбивая куски с split по символам IFS.
+// Пробельные символы IFS схлопываются, остальные разделяют поля каждый
+func split(items []item, ifs string) []field {
+	var (
+		fields  []field
+		cur     field
+		content bool
+	)
+	flush := func(force bool) {
+		if content || force {
+			fields = append(fields, cur)
+		}
+		cur, content = field{}, false
+	}
+	for _, it := range items {
+		switch {
+		case it.brk:
+			flush(true)
+		case !it.split || ifs == "":
+			cur.text += it.text
+			if it.quoted {
+				cur.pattern += pattern.Escape(it.text)
+			} else {
+				cur.pattern += it.text
+			}
+			content = content || it.quoted || it.text != ""
+		default:
+			for _, r := range it.text {
+				if !strings.ContainsRune(ifs, r) {
+					cur.text += string(r)
+					cur.pattern += string(r)
+					content = true
+					continue
+				}
+				if r == ' ' || r == '\t' || r == '\n' {
+					flush(false)
+				} else {
+					flush(true)
+				}
+			}
+		}
+	}
+	flush(false)
+	return fields
+}
+
+// expandWord разбирает слово и выполняет подстановки
+func expandWord(word string, env Env, assign bool) ([]item, error) {
+	e := &expander{env: env, assign: assign}
+	if err := e.expand(word, false); err != nil {
+		return nil, err
+	}
+	return e.items, nil
+}
+
+type expander struct {
+	env   Env
+	items []item
+	// atSeen выставляется, когда "$@" подставлен внутри кавычек:
+	// пустой "$@" не даёт поля, в отличие от ""
+	atSeen bool
+	// assign — слово является значением присваивания
+	assign bool
+	// heredoc — раскрывается тело here-document, где " не экранируется
+	heredoc bool
+}
+
+func (e *expander) literal(text string, quoted bool) {
+	e.items = append(e.items, item{text: text, quoted: quoted})
+}
+
+// value добавляет результат подстановки: в кавычках он остаётся одним куском,
+// вне кавычек подлежит разбиению на поля
+func (e *expander) value(text string, quoted bool) {
+	e.items = append(e.items, item{text: text, quoted: quoted, split: !quoted})
+}
+
+// expand обрабатывает слово; dq — слово находится внутри двойных кавычек
+func (e *expander) expand(s string, dq bool) error {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\'' && !dq:
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return fmt.Errorf("unexpected EOF while looking for matching `''")
+			}
+			e.literal(s[i+1:i+1+end], true)
+			i += end + 2
+		case c == '"' && !dq:
+			end := closingQuote(s, i)
+			n, atSeen := len(e.items), e.atSeen
+			e.atSeen = false
+			if err := e.expand(s[i+1:end], true); err != nil {
+				return err
+			}
+			if len(e.items) == n && !e.atSeen {
+				// "" даёт пустое поле
+				e.literal("", true)
+			}
+			e.atSeen = e.atSeen || atSeen
+			i = end + 1
+		case c == '\\':
+			if i+1 >= len(s) {
+				e.literal("\\", dq)
+				i++
+				continue
+			}
+			next := s[i+1]
+			switch {
+			case next == '\n':
+			case !dq || strings.IndexByte("$`\\", next) >= 0 || (next == '"' && !e.heredoc):
+				e.literal(string(next), true)
+			default:
+				// внутри кавычек обратный слэш перед обычным символом сохраняется
+				e.literal(s[i:i+2], true)
+			}
+			i += 2
+		case (c == '<' || c == '>') && !dq && i == 0 && strings.HasPrefix(s[1:], "("):
+			// лексер допускает подстановку процесса только в начале слова
+			end := matching(s, 1)
+			if end >= len(s) {
+				return fmt.Errorf("unexpected EOF while looking for matching `)'")
+			}
+			path, err := e.env.ProcSubst(s[2:end], c == '>')
+			if err != nil {
+				return err
+			}
+			e.literal(path, true)
+			i = end + 1
+		case c == '~' && !dq && (i == 0 || (e.assign && s[i-1] == ':')):
+			i = e.tilde(s, i)
+		case c == '$':
+			n, err := e.dollar(s, i, dq)
+			if err != nil {
+				return err
+			}
+			i = n
+		case c == '`':
+			end := closingBackquote(s, i)
+			if end >= len(s) {
+				return fmt.Errorf("unexpected EOF while looking for matching ``'")
+			}
+			if err := e.subst(unescapeBackquoted(s[i+1:end], dq), dq); err != nil {
+				return err
+			}
+			i = end + 1
+		default:
+			j := i + 1
+			for j < len(s) && strings.IndexByte("'\"\\$`~", s[j]) < 0 {
+				j++
+			}
+			e.literal(s[i:j], dq)
+			i = j
+		}
+	}
+	return nil
+}
+
+// tilde раскрывает ~ в позиции i: ~ — домашний каталог из $HOME, ~user —
+// каталог пользователя, ~+ и ~- — $PWD и $OLDPWD. Префикс с кавычками или
+// неизвестным пользователем остаётся как есть. Возвращает индекс после префикса
+func (e *expander) tilde(s string, i int) int {
+	end := i + 1
+	for end < len(s) && s[end] != '/' && !(e.assign && s[end] == ':') {
+		end++
+	}
+	name := s[i+1 : end]
+	if strings.ContainsAny(name, "'\"\\$`") {
+		e.literal("~", false)
+		return i + 1
+	}
+
+	var dir string
+	ok := true
+	switch name {
+	case "":
+		dir, ok = e.env.Var("HOME")
+	case "+":
+		dir, ok = e.env.Var("PWD")
+	case "-":
+		dir, ok = e.env.Var("OLDPWD")
+	default:
+		u, err := user.Lookup(name)
+		if err == nil {
+			dir = u.HomeDir
+		}
+		ok = err == nil
+	}
+	if !ok {
+		e.literal(s[i:end], false)
+		return end
+	}
+	// результат не разбивается на поля и не раскрывается как шаблон
+	e.literal(dir, true)
+	return end
+}
+
+// closingQuote возвращает индекс закрывающей двойной кавычки для открывающей в i
+func closingQuote(s string, i int) int {
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		case '`':
+			i = closingBackquote(s, i)
+		case '$':
+			if i+1 < len(s) && (s[i+1] == '{' || s[i+1] == '(') {
+				i = matching(s, i+1)
+			}
+		}
+	}
+	return len(s)
+}
+
+// closingBackquote возвращает индекс обратной кавычки, закрывающей открытую в i
+func closingBackquote(s string, i int) int {
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '`':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// matching возвращает индекс скобки, парной к { или ( в позиции i
+func matching(s string, i int) int {
+	open := s[i]
+	closing := byte('}')
+	if open == '(' {
+		closing = ')'
+	}
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case open:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		case '\\':
+			i++
+		case '\'':
+			if end := strings.IndexByte(s[i+1:], '\''); end >= 0 {
+				i += end + 1
+			}
+		case '"':
+			i = closingQuote(s, i)
+		case '`':
+			i = closingBackquote(s, i)
+		case '$':
+			if i+1 < len(s) && (s[i+1] == '{' || s[i+1] == '(') && s[i+1] != open {
+				i = matching(s, i+1)
+			}
+		}
+	}
+	return len(s)
+}
+
+// unescapeBackquoted снимает экранирование внутри `...`: обратный слэш
+// сохраняет буквальный смысл, кроме случаев перед $, ` и \ (и " внутри кавычек)
+func unescapeBackquoted(s string, dq bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && (strings.IndexByte("$`\\", s[i+1]) >= 0 || (dq && s[i+1] == '"')) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// subst подставляет вывод команды без завершающих переводов строк
+func (e *expander) subst(script string, dq bool) error {
+	out, err := e.env.Subst(script)
+	if err != nil {
+		return err
+	}
+	e.value(strings.TrimRight(out, "\n"), dq)
+	return nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || ('0' <= c && c <= '9')
+}
+
+func isSpecial(c byte) bool {
+	return strings.IndexByte("?#@*$!-0123456789", c) >= 0
+}
+
+// dollar раскрывает подстановку, начинающуюся с $ в позиции i, и возвращает индекс после неё
+func (e *expander) dollar(s string, i int, dq bool) (int, error) {
+	if i+1 >= len(s) {
+		e.literal("$", dq)
+		return i + 1, nil
+	}
+	c := s[i+1]
+	switch {
+	case c == '(':
+		end := matching(s, i+1)
+		if end >= len(s) {
+			return 0, fmt.Errorf("unexpected EOF while looking for matching `)'")
+		}
+		body := s[i+2 : end]
+		if len(body) >= 2 && body[0] == '(' && matching(body, 0) == len(body)-1 {
+			n, err := Arith(body[1:len(body)-1], e.env)
+			if err != nil {
+				return 0, err
+			}
+			e.value(strconv.FormatInt(n, 10), dq)
+			return end + 1, nil
+		}
+		if err := e.subst(body, dq); err != nil {
+			return 0, err
+		}
+		return end + 1, nil
+	case c == '{':
+		end := matching(s, i+1)
+		if end >= len(s) {
+			return 0, fmt.Errorf("%s: bad substitution", s[i:])
+		}
+		if err := e.braced(s[i+2:end], dq); err != nil {
+			return 0, err
+		}
+		return end + 1, nil
+	case isNameStart(c):
+		j := i + 1
+		for j < len(s) && isNameChar(s[j]) {
+			j++
+		}
+		e.param(s[i+1:j], dq)
+		return j, nil
+	case isSpecial(c):
+		e.param(string(c), dq)
+		return i + 2, nil
+	default:
+		e.literal("$", dq)
+		return i + 1, nil
+	}
+}
+
+// param подставляет значение параметра; $@ и $* разворачиваются в позиционные параметры
+func (e *expander) param(name string, dq bool) {
+	switch name {
+	case "@":
+		args := e.env.Args()
+		if dq {
+			e.atSeen = true
+		}
+		for i, arg := range args {
+			if i > 0 {
+				e.items = append(e.items, item{brk: true})
+			}
+			e.value(arg, dq)
+		}
+		return
+	case "*":
+		sep := " "
+		if v := ifs(e.env); v != " \t\n" {
+			sep = ""
+			if v != "" {
+				sep = v[:1]
+			}
+		}
+		if !dq {
+			for i, arg := range e.env.Args() {
+				if i > 0 {
+					e.items = append(e.items, item{brk: true})
+				}
+				e.value(arg, false)
+			}
+			return
+		}
+		e.value(strings.Join(e.env.Args(), sep), true)
+		return
+	}
+	v, _ := e.env.Var(name)
+	e.value(v, dq)
+}
+
+// braced раскрывает ${...}: ${NAME}, ${#NAME}, ${NAME op word}, где op —
+// один из :- - := = :? ? :+ + # ## % %%
+func (e *expander) braced(body string, dq bool) error {
+	if len(body) > 1 && body[0] == '#' {
+		name := body[1:]
+		if !validParam(name) {
+			return fmt.Errorf("${%s}: bad substitution", body)
+		}
+		if name == "@" || name == "*" {
+			e.value(strconv.Itoa(len(e.env.Args())), dq)
+			return nil
+		}
+		v, _ := e.env.Var(name)
+		e.value(strconv.Itoa(utf8.RuneCountInString(v)), dq)
+		return nil
+	}
+
+	name, rest := splitParam(body)
+	if name == "" {
+		return fmt.Errorf("${%s}: bad substitution", body)
+	}
+	if rest == "" {
+		e.param(name, dq)
+		return nil
+	}
+
+	op := rest[:1]
+	if op == ":" && len(rest) > 1 {
+		op = rest[:2]
+	} else if (op == "#" || op == "%") && len(rest) > 1 && rest[1] == rest[0] {
+		op = rest[:2]
+	}
+	word := rest[len(op):]
+
+	v, set := e.env.Var(name)
+	if name == "@" || name == "*" {
+		v, set = strings.Join(e.env.Args(), " "), len(e.env.Args()) > 0
+	}
+	// с : пустое значение считается неустановленным
+	unset := !set || (op[0] == ':' && v == "")
+
+	switch op {
+	case "-", ":-":
+		if unset {
+			return e.expand(word, dq)
+		}
+		e.param(name, dq)
+	case "=", ":=":
+		if unset {
+			value, err := Literal(word, e.env)
+			if err != nil {
+				return err
+			}
+			e.env.SetVar(name, value)
+			v = value
+		}
+		e.value(v, dq)
+	case "?", ":?":
+		if unset {
+			msg := "parameter null or not set"
+			if word != "" {
+				var err error
+				if msg, err = Literal(word, e.env); err != nil {
+					return err
+				}
+			}
+			return fmt.Errorf("%s: %s", name, msg)
+		}
+		e.value(v, dq)
+	case "+", ":+":
+		if !unset {
+			return e.expand(word, dq)
+		}
+	case "#", "##", "%", "%%":
+		pat, err := Pattern(word, e.env)
+		if err != nil {
+			return err
+		}
+		e.value(trim(v, pat, op), dq)
+	default:
+		return fmt.Errorf("${%s}: bad substitution", body)
+	}
+	return nil
+}
+
+// splitParam отделяет имя параметра от оператора
+func splitParam(body string) (string, string) {
+	if body == "" {
+		return "", ""
+	}
+	if isSpecial(body[0]) && !('0' <= body[0] && body[0] <= '9') {
+		return body[:1], body[1:]
+	}
+	j := 0
+	if '0' <= body[0] && body[0] <= '9' {
+		for j < len(body) && '0' <= body[j] && body[j] <= '9' {
+			j++
+		}
+		return body[:j], body[j:]
+	}
+	for j < len(body) && isNameChar(body[j]) {
+		j++
+	}
+	// индекс массива PIPESTATUS[N]
+	if j > 0 && j < len(body) && body[j] == '[' {
+		if end := strings.IndexByte(body[j:], ']'); end >= 0 {
+			j += end + 1
+		}
+	}
+	return body[:j], body[j:]
+}
+
+func validParam(name string) bool {
+	n, rest := splitParam(name)
+	return n != "" && rest == ""
+}
+
+// trim удаляет из v кратчайший (# и %) или длиннейший (## и %%) префикс или суффикс,
+// совпадающий с шаблоном
+func trim(v, pat, op string) string {
+	switch op {
+	case "#":
+		for i := 0; i <= len(v); i++ {
+			if pattern.Match(pat, v[:i]) {
+				return v[i:]
+			}
+		}
+	case "##":
+		for i := len(v); i >= 0; i-- {
+			if pattern.Match(pat, v[:i]) {
+				return v[i:]
+			}
+		}
+	case "%":
+		for i := len(v); i >= 0; i-- {
+			if pattern.Match(pat, v[i:]) {
+				return v[:i]
+			}
+		}
+	case "%%":
+		for i := 0; i <= len(v); i++ {
+			if pattern.Match(pat, v[i:]) {
+				return v[:i]
+			}
+		}
+	}
+	return v
+}
@@ -0,0 +1,144 @@
+package interp
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"wb-l2/internal/builtins"
+	"wb-l2/internal/lineedit"
+)
+
+// complete дополняет слово перед курсором: $NAME — именами переменных,
+// слово в позиции команды — именами команд,
+// остальные слова — путями к файлам
+func (s *Shell) complete(line string) (int, []lineedit.Candidate) {
+	start := strings.LastIndexAny(line, " \t\n|&;<>()") + 1
+	// экранированный пробел принадлежит слову
+	for start > 1 && line[start-2] == '\\' {
+		start = strings.LastIndexAny(line[:start-2], " \t\n|&;<>()") + 1
+	}
+	word := line[start:]
+
+	if strings.HasPrefix(word, "$") && !strings.ContainsAny(word, "{(") {
+		return start, s.completeVars(word[1:])
+	}
+	if isCommandPosition(line[:start]) && !strings.Contains(word, "/") {
+		return start, s.completeCommands(word)
+	}
+	return start, s.completeFiles(word)
+}
+
+// isCommandPosition сообщает, что слово после before будет именем команды
+func isCommandPosition(before string) bool {
+	before = strings.TrimRight(before, " \t")
+	if before == "" {
+		return true
+	}
+	if strings.ContainsAny(before[len(before)-1:], "|&;(\n") {
+		return true
+	}
+	fields := strings.Fields(before)
+	switch fields[len(fields)-1] {
+	case "then", "else", "do", "if", "elif", "while", "until", "!", "{":
+		return true
+	}
+	return false
+}
+
+func (s *Shell) completeVars(prefix string) []lineedit.Candidate {
+	var out []lineedit.Candidate
+	for name := range s.vars {
+		if strings.HasPrefix(name, prefix) {
+			out = append(out, lineedit.Candidate{Text: "$" + name, Display: name})
+		}
+	}
+	return out
+}
+
+// completeCommands перечисляет псевдонимы, функции, встроенные команды
+// и исполняемые файлы из $PATH
+func (s *Shell) completeCommands(prefix string) []lineedit.Candidate {
+	seen := make(map[string]bool)
+	var out []lineedit.Candidate
+	add := func(name string) {
+		if !seen[name] && strings.HasPrefix(name, prefix) {
+			seen[name] = true
+			out = append(out, lineedit.Candidate{Text: escapeWord(name), Display: name})
+		}
+	}
+	for name := range s.aliases {
+		add(name)
+	}
+	for name := range s.funcs {
+		add(name)
+	}
+	for _, name := range builtins.Names() {
+		add(name)
+	}
+	path, _ := s.Var("PATH")
+	for _, name := range builtins.Executables(path, s.dir, prefix) {
+		add(name)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Display < out[j].Display })
+	return out
+}
+
+// completeFiles перечисляет файлы, имена которых начинаются с word.
+// Каталоги дополняются косой чертой, скрытые файлы — только если word начинается с точки
+func (s *Shell) completeFiles(word string) []lineedit.Candidate {
+	word = unescapeWord(word)
+	dir, base := filepath.Split(word)
+	lookup := dir
+	if strings.HasPrefix(dir, "~/") || dir == "~" {
+		home, _ := s.Var("HOME")
+		lookup = home + dir[1:]
+	}
+	entries, err := os.ReadDir(s.abs(lookup))
+	if err != nil {
+		return nil
+	}
+
+	var out []lineedit.Candidate
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		display := name
+		if fi, err := os.Stat(filepath.Join(s.abs(lookup), name)); err == nil && fi.IsDir() {
+			display += "/"
+		}
+		text := escapeWord(dir + display)
+		if strings.HasPrefix(dir, "~") {
+			// тильда должна остаться нераскрытой и без экранирования
+			text = "~" + escapeWord(dir[1:]+display)
+		}
+		out = append(out, lineedit.Candidate{Text: text, Display: display})
+	}
+	return out
+}
+
+// escapeWord экранирует обратной косой чертой символы, особые для шелла
+func escapeWord(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(" \t\n|&;<>()$`\\\"'*?[#~{}!", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func unescapeWord(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
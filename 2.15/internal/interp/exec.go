@@ -0,0 +1,703 @@
+package interp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"wb-l2/internal/builtins"
+	"wb-l2/internal/executor"
+	"wb-l2/internal/expand"
+	"wb-l2/internal/model"
+	"wb-l2/internal/parser"
+	"wb-l2/internal/pattern"
+)
+
+// aborted сообщает, что выполнение текущего списка нужно прекратить:
+// был exit, return, break, continue, Ctrl+C или отменён контекст Run
+func (s *Shell) aborted() bool {
+	return s.exiting || s.returning || s.breakN > 0 || s.contN > 0 || s.interrupted || s.ctx.Err() != nil
+}
+
+// runList выполняет элементы списка по порядку
+func (s *Shell) runList(list *parser.List, stdio executor.Stdio) {
+	for _, item := range list.Items {
+		s.runTraps()
+		if s.aborted() {
+			return
+		}
+		if item.Background {
+			s.runBackground(item.AndOr, stdio)
+			continue
+		}
+		s.runAndOr(item.AndOr, stdio)
+	}
+}
+
+// runBackground запускает and-or список как фоновое задание. Пайплайн из простых
+// команд запускается напрямую, остальное — в дочернем шелле. Команды >(...)
+// не ждут: их каналы держит задание
+func (s *Shell) runBackground(andOr *parser.AndOr, stdio executor.Stdio) {
+	defer s.closeProcSubsts(len(s.procSubsts), false)
+	if len(andOr.Pipelines) == 1 && !andOr.Pipelines[0].Negate {
+		pl := andOr.Pipelines[0]
+		if cmds, ok := s.simpleCommands(pl); ok {
+			s.launch(cmds, pl.String(), stdio, true)
+			return
+		}
+	}
+	s.launch([]model.Command{s.subshell(andOr.Text)}, andOr.Text, stdio, true)
+}
+
+// runAndOr выполняет пайплайны слева направо: после && следующий запускается
+// только при успехе предыдущего, после || — только при неудаче.
+// Пропущенный пайплайн не меняет $?. Ловушка ERR срабатывает, если неудачей
+// закончился последний пайплайн списка вне условия if, while или until и без !
+func (s *Shell) runAndOr(andOr *parser.AndOr, stdio executor.Stdio) {
+	s.runPipeline(andOr.Pipelines[0], stdio)
+	last := 0
+	for i, op := range andOr.Ops {
+		if s.aborted() {
+			return
+		}
+		if (op == parser.TokAndIf) != (s.status == 0) {
+			continue
+		}
+		s.runPipeline(andOr.Pipelines[i+1], stdio)
+		last = i + 1
+	}
+	if s.status != 0 && last == len(andOr.Pipelines)-1 && !andOr.Pipelines[last].Negate &&
+		s.condDepth == 0 && !s.aborted() {
+		s.runTrap("ERR")
+	}
+}
+
+// runPipeline выполняет пайплайн переднего плана и обновляет $? и $PIPESTATUS.
+// Подстановки процессов в его словах закрываются после его завершения
+// или остановки.
+// Одиночная составная команда или функция выполняется в текущем шелле,
+// составные стадии и функции в длинном пайплайне — в дочерних шеллах
+func (s *Shell) runPipeline(pl *parser.Pipeline, stdio executor.Stdio) {
+	defer func(n int) { s.closeProcSubsts(n, !stopped(s.pipeStatus)) }(len(s.procSubsts))
+	if len(pl.Commands) == 1 {
+		if simple, ok := pl.Commands[0].(*parser.SimpleCommand); ok {
+			s.runSimple(simple, pl, stdio)
+		} else {
+			s.runCompound(pl.Commands[0], stdio)
+			s.pipeStatus = []int{s.status}
+		}
+		s.negate(pl)
+		return
+	}
+
+	cmds := make([]model.Command, len(pl.Commands))
+	for i, c := range pl.Commands {
+		simple, ok := c.(*parser.SimpleCommand)
+		if !ok || len(simple.Words) == 0 {
+			cmds[i] = s.subshell(c.String())
+			continue
+		}
+		cmd, err := s.expand(simple)
+		if err != nil {
+			s.expansionError(err)
+			return
+		}
+		var fn *parser.FuncDef
+		if len(cmd.Args) > 0 {
+			cmd, fn = s.resolve(cmd)
+		}
+		switch {
+		case len(cmd.Args) == 0:
+			cmd = s.subshell(":")
+		case fn != nil:
+			cmd = s.subshell(c.String())
+		}
+		cmds[i] = cmd
+	}
+	statuses := s.launch(cmds, pl.String(), stdio, false)
+	s.pipeStatus = statuses
+	s.status = pipelineStatus(statuses, s.options["pipefail"])
+	s.negate(pl)
+}
+
+// runSimple выполняет одиночную простую команду. Команда без слов присваивает
+// переменные шелла и открывает файлы перенаправлений; префиксы присваиваний встроенной команды действуют только на время её работы
+func (s *Shell) runSimple(c *parser.SimpleCommand, pl *parser.Pipeline, stdio executor.Stdio) {
+	cmd, err := s.expand(c)
+	if err != nil {
+		s.expansionError(err)
+		return
+	}
+	if len(cmd.Args) == 0 {
+		for _, kv := range cmd.Env {
+			name, value, _ := strings.Cut(kv, "=")
+			s.SetVar(name, value)
+		}
+		s.status = s.substStatus
+		if len(cmd.Redirects) > 0 {
+			// команда из одних перенаправлений только создаёт или открывает файлы
+			_, closeFiles, err := executor.Redirect(stdio, cmd.Redirects, s.dir, s.options["noclobber"])
+			if err != nil {
+				fmt.Fprintf(s.stdio.Err, "mini-sh: %v\n", err)
+				s.status = 1
+			} else {
+				closeFiles()
+			}
+		}
+		s.pipeStatus = []int{s.status}
+		return
+	}
+
+	cmd, fn := s.resolve(cmd)
+	if fn != nil {
+		s.callFunction(fn, cmd, stdio)
+		s.pipeStatus = []int{s.status}
+		return
+	}
+	if builtins.IsBuiltin(cmd.Args[0]) && len(cmd.Env) > 0 {
+		defer s.restoreVars(cmd.Env)()
+		for _, kv := range cmd.Env {
+			name, value, _ := strings.Cut(kv, "=")
+			s.SetVar(name, value)
+		}
+	}
+	statuses := s.launch([]model.Command{cmd}, pl.String(), stdio, false)
+	s.pipeStatus = statuses
+	s.status = pipelineStatus(statuses, s.options["pipefail"])
+}
+
+// restoreVars запоминает переменные из списка NAME=value и возвращает функцию,
+// восстанавливающую их прежние значения
+func (s *Shell) restoreVars(env []string) func() {
+	saved := make(map[string]*variable, len(env))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if v, ok := s.vars[name]; ok {
+			copied := *v
+			saved[name] = &copied
+		} else {
+			saved[name] = nil
+		}
+	}
+	return func() {
+		for name, v := range saved {
+			if v == nil {
+				s.UnsetVar(name)
+				continue
+			}
+			s.SetVar(name, v.value)
+		}
+	}
+}
+
+// expansionError сообщает об ошибке раскрытия; команда не выполняется.
+// Неинтерактивный шелл, как в POSIX, при этом завершается с кодом 2
+func (s *Shell) expansionError(err error) {
+	fmt.Fprintf(s.stdio.Err, "mini-sh: %v\n", err)
+	s.status = 1
+	s.pipeStatus = []int{1}
+	if !s.interactive {
+		s.Exit(2)
+	}
+}
+
+func (s *Shell) negate(pl *parser.Pipeline) {
+	if !pl.Negate {
+		return
+	}
+	if s.status == 0 {
+		s.status = 1
+	} else {
+		s.status = 0
+	}
+}
+
+// simpleCommands возвращает раскрытые стадии, если все они простые команды
+// с аргументами и среди них нет вызовов функций
+func (s *Shell) simpleCommands(pl *parser.Pipeline) ([]model.Command, bool) {
+	cmds := make([]model.Command, len(pl.Commands))
+	for i, c := range pl.Commands {
+		simple, ok := c.(*parser.SimpleCommand)
+		if !ok || len(simple.Words) == 0 {
+			return nil, false
+		}
+		cmd, err := s.expand(simple)
+		if err != nil || len(cmd.Args) == 0 {
+			return nil, false
+		}
+		cmd, fn := s.resolve(cmd)
+		if fn != nil {
+			return nil, false
+		}
+		cmds[i] = cmd
+	}
+	return cmds, true
+}
+
+// resolve снимает с команды префиксы command без опций и возвращает функцию,
+// которую она вызывает. После command функции не ищутся: выполняется
+// встроенная команда или внешний файл
+func (s *Shell) resolve(cmd model.Command) (model.Command, *parser.FuncDef) {
+	bypass := false
+	for len(cmd.Args) > 1 && cmd.Args[0] == "command" {
+		switch {
+		case (cmd.Args[1] == "--" || cmd.Args[1] == "-p") && len(cmd.Args) > 2:
+			cmd.Args = cmd.Args[2:]
+		case !strings.HasPrefix(cmd.Args[1], "-"):
+			cmd.Args = cmd.Args[1:]
+		default:
+			return cmd, nil
+		}
+		bypass = true
+	}
+	if bypass {
+		return cmd, nil
+	}
+	return cmd, s.funcs[cmd.Args[0]]
+}
+
+// launch передаёт пайплайн исполнителю. Ctrl+C прерывает оставшуюся часть строки,
+// а в скрипте — весь скрипт, если команда не перехватила SIGINT сама
+func (s *Shell) launch(cmds []model.Command, text string, stdio executor.Stdio, background bool) []int {
+	// Ctrl+C, нажатый в приглашении, не должен отменять следующую команду,
+	// а SIGINT, полученный скриптом между командами, прерывает его
+	select {
+	case <-s.sigCh:
+		if !s.interactive && !s.trapped("INT") {
+			s.interrupted = true
+			return slices.Repeat([]int{128 + int(syscall.SIGINT)}, len(cmds))
+		}
+	default:
+	}
+	if s.ctx.Err() != nil {
+		s.interrupted = true
+		return slices.Repeat([]int{128 + int(syscall.SIGINT)}, len(cmds))
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+	done := make(chan struct{})
+	gotInt := make(chan bool, 1)
+	go func() {
+		select {
+		case <-s.sigCh:
+			// интерактивный шелл получает SIGINT, только пока команда переднего плана
+			// не владеет терминалом, например во время встроенной команды;
+			// процессам сигнал передаёт исполнитель
+			if s.interactive {
+				cancel()
+			}
+			gotInt <- true
+		case <-ctx.Done():
+			// отменён контекст Run: исполнитель сам прерывает процессы
+			gotInt <- true
+		case <-done:
+			gotInt <- false
+		}
+	}()
+
+	statuses := executor.RunPipeline(ctx, s, s.withProcSubsts(stdio), cmds, text, background)
+	close(done)
+	received := <-gotInt
+	killed := slices.Contains(statuses, 128+int(syscall.SIGINT))
+
+	switch {
+	case s.trapped("INT"):
+		// Ctrl+C получило задание со своим терминалом, а не шелл; ловушка
+		// выполняется, как если бы сигнал получил шелл
+		if s.interactive && killed && !received {
+			s.runTrap("INT")
+		}
+	case s.interactive:
+		if received || killed {
+			s.interrupted = true
+		}
+	case received:
+		// скрипт ждёт завершения команды и прерывается, только если она погибла
+		// от SIGINT, а не обработала его; встроенные команды SIGINT не обрабатывают
+		if killed || !slices.ContainsFunc(cmds, func(c model.Command) bool { return !builtins.IsBuiltin(c.Args[0]) }) {
+			s.interrupted = true
+		}
+	}
+	if s.ctx.Err() != nil {
+		s.interrupted = true
+	}
+	return statuses
+}
+
+// trapped сообщает, что для условия name установлена ловушка, в том числе пустая
+func (s *Shell) trapped(name string) bool {
+	_, ok := s.traps[name]
+	return ok
+}
+
+// Переменные окружения, которыми дочерний процесс подоболочки получает
+// состояние родительского шелла: $$, $?, стек каталогов pushd и преамбулу —
+// команды, которые новый шелл выполняет до скрипта. В переменные шелла
+// они не попадают
+const (
+	parentPidVar = "MINI_SH_PARENT_PID"
+	statusVar    = "MINI_SH_STATUS"
+	dirsVar      = "MINI_SH_DIRS"
+	preambleVar  = "MINI_SH_PREAMBLE"
+)
+
+// subshell возвращает команду, выполняющую script в дочернем процессе шелла
+// с теми же $0, $$, $?, позиционными параметрами и стеком каталогов.
+// Неэкспортируемые переменные, опции, игнорируемые сигналы, псевдонимы
+// и функции передаются преамбулой, после которой восстанавливается $?.
+// Без исполняемого файла шелла скрипт выполнит Subshell в копии шелла
+func (s *Shell) subshell(script string) model.Command {
+	if s.self == "" {
+		return model.Command{Args: []string{s.name}, Script: script}
+	}
+	var preamble strings.Builder
+	for _, kv := range s.Locals() {
+		name, value, _ := strings.Cut(kv, "=")
+		fmt.Fprintf(&preamble, "%s=%s\n", name, parser.Quote(value))
+	}
+	for name, on := range s.options {
+		if on {
+			fmt.Fprintf(&preamble, "set -o %s\n", name)
+		}
+	}
+	// как и в Subshell, из ловушек остаётся только игнорирование
+	for _, name := range slices.Sorted(maps.Keys(s.traps)) {
+		if s.traps[name] == "" {
+			fmt.Fprintf(&preamble, "trap '' %s\n", name)
+		}
+	}
+	preamble.WriteString(s.definitions())
+	args := append([]string{s.self, "-c", script, s.name}, s.args...)
+	env := []string{
+		parentPidVar + "=" + strconv.Itoa(s.pid),
+		statusVar + "=" + strconv.Itoa(s.status),
+		preambleVar + "=" + preamble.String(),
+	}
+	if len(s.dirs) > 0 {
+		env = append(env, dirsVar+"="+strings.Join(s.dirs, "\n"))
+	}
+	return model.Command{Args: args, Env: env}
+}
+
+// Subshell выполняет скрипт подоболочки в копии шелла внутри процесса.
+// Как и в дочернем процессе, ловушки сбрасываются, кроме игнорирования
+// сигналов, а exit завершает только подоболочку. Закрытые дескрипторы 0-2
+// заменяются на /dev/null
+func (s *Shell) Subshell(script string, files []*os.File) int {
+	f := s.Fork().(*Shell)
+	files = slices.Clone(files)
+	for i := range 3 {
+		if files[i] == nil {
+			null, err := os.OpenFile(os.DevNull, os.O_RDWR, 0)
+			if err != nil {
+				fmt.Fprintf(s.stdio.Err, "mini-sh: %v\n", err)
+				return 1
+			}
+			defer null.Close()
+			files[i] = null
+		}
+	}
+	f.stdio = executor.Stdio{In: files[0], Out: files[1], Err: files[2], Extra: files[3:]}
+	maps.DeleteFunc(f.traps, func(_, action string) bool { return action != "" })
+	f.loopDepth, f.breakN, f.contN = 0, 0, 0
+	f.exiting, f.returning, f.interrupted = false, false, false
+	f.RunReader(strings.NewReader(script), "", false)
+	f.RunExitTrap()
+	return f.ExitCode()
+}
+
+// runCompound выполняет составную команду в текущем шелле
+func (s *Shell) runCompound(cmd parser.Command, stdio executor.Stdio) {
+	switch c := cmd.(type) {
+	case *parser.Group:
+		s.withRedirects(&c.Compound, stdio, func(stdio executor.Stdio) {
+			s.runList(c.Body, stdio)
+		})
+	case *parser.Subshell:
+		s.withRedirects(&c.Compound, stdio, func(stdio executor.Stdio) {
+			statuses := s.launch([]model.Command{s.subshell(c.Script)}, c.Text, stdio, false)
+			s.status = statuses[0]
+		})
+	case *parser.IfClause:
+		s.withRedirects(&c.Compound, stdio, func(stdio executor.Stdio) {
+			s.runIf(c, stdio)
+		})
+	case *parser.WhileClause:
+		s.withRedirects(&c.Compound, stdio, func(stdio executor.Stdio) {
+			s.runWhile(c, stdio)
+		})
+	case *parser.ForClause:
+		s.withRedirects(&c.Compound, stdio, func(stdio executor.Stdio) {
+			s.runFor(c, stdio)
+		})
+	case *parser.CaseClause:
+		s.withRedirects(&c.Compound, stdio, func(stdio executor.Stdio) {
+			s.runCase(c, stdio)
+		})
+	case *parser.FuncDef:
+		s.funcs[c.Name] = c
+		s.status = 0
+	}
+}
+
+// withRedirects открывает файлы перенаправлений составной команды и выполняет fn
+// с изменёнными потоками
+func (s *Shell) withRedirects(c *parser.Compound, stdio executor.Stdio, fn func(executor.Stdio)) {
+	if len(c.Redirects) == 0 {
+		fn(stdio)
+		return
+	}
+	redirects, err := s.redirects(c.Redirects)
+	if err != nil {
+		s.expansionError(err)
+		return
+	}
+	stdio, closeFiles, err := executor.Redirect(stdio, redirects, s.dir, s.options["noclobber"])
+	if err != nil {
+		fmt.Fprintf(s.stdio.Err, "mini-sh: %v\n", err)
+		s.status = 1
+		return
+	}
+	defer closeFiles()
+	fn(stdio)
+}
+
+func (s *Shell) runIf(c *parser.IfClause, stdio executor.Stdio) {
+	for i, cond := range c.Conds {
+		s.runCond(cond, stdio)
+		if s.aborted() {
+			return
+		}
+		if s.status == 0 {
+			s.runList(c.Bodies[i], stdio)
+			return
+		}
+	}
+	if c.Else != nil {
+		s.runList(c.Else, stdio)
+		return
+	}
+	s.status = 0
+}
+
+// runCond выполняет условие if, while или until: неудача в нём не вызывает ловушку ERR
+func (s *Shell) runCond(cond *parser.List, stdio executor.Stdio) {
+	s.condDepth++
+	defer func() { s.condDepth-- }()
+	s.runList(cond, stdio)
+}
+
+func (s *Shell) runWhile(c *parser.WhileClause, stdio executor.Stdio) {
+	s.loopDepth++
+	defer func() { s.loopDepth-- }()
+
+	status := 0
+	for {
+		s.runCond(c.Cond, stdio)
+		if s.loopEnd() {
+			break
+		}
+		if (s.status == 0) == c.Until {
+			break
+		}
+		s.runList(c.Body, stdio)
+		status = s.status
+		if s.loopEnd() {
+			break
+		}
+	}
+	if !s.exiting && !s.returning && !s.interrupted {
+		s.status = status
+	}
+}
+
+func (s *Shell) runFor(c *parser.ForClause, stdio executor.Stdio) {
+	s.loopDepth++
+	defer func() { s.loopDepth-- }()
+
+	values := s.args
+	if c.HasIn {
+		values = nil
+		for _, w := range c.Words {
+			fields, err := expand.Fields(w.Raw, s)
+			if err != nil {
+				s.expansionError(err)
+				return
+			}
+			values = append(values, fields...)
+		}
+	}
+
+	s.status = 0
+	for _, value := range values {
+		s.SetVar(c.Var, value)
+		s.runList(c.Body, stdio)
+		if s.loopEnd() {
+			break
+		}
+	}
+}
+
+// loopEnd обрабатывает break и continue после итерации и сообщает,
+// нужно ли завершить текущий цикл
+func (s *Shell) loopEnd() bool {
+	if s.exiting || s.returning || s.interrupted {
+		return true
+	}
+	if s.breakN > 0 {
+		s.breakN--
+		return true
+	}
+	if s.contN > 0 {
+		// continue N > 1 завершает этот цикл и продолжает внешний
+		s.contN--
+		return s.contN > 0
+	}
+	return false
+}
+
+func (s *Shell) runCase(c *parser.CaseClause, stdio executor.Stdio) {
+	word, err := expand.Literal(c.Word.Raw, s)
+	if err != nil {
+		s.expansionError(err)
+		return
+	}
+	for _, item := range c.Items {
+		for _, p := range item.Patterns {
+			pat, err := expand.Pattern(p.Raw, s)
+			if err != nil {
+				s.expansionError(err)
+				return
+			}
+			if pattern.Match(pat, word) {
+				s.status = 0
+				s.runList(item.Body, stdio)
+				return
+			}
+		}
+	}
+	s.status = 0
+}
+
+// expand раскрывает простую команду непосредственно перед запуском, чтобы $?
+// видел результат предыдущей команды той же строки. Префиксы присваиваний
+// попадают в Env. Код последней подстановки команды сохраняется в substStatus
+func (s *Shell) expand(c *parser.SimpleCommand) (model.Command, error) {
+	var cmd model.Command
+	s.substStatus = 0
+	for _, a := range c.Assigns {
+		value, err := expand.Assignment(a.Value.Raw, s)
+		if err != nil {
+			return cmd, err
+		}
+		cmd.Env = append(cmd.Env, a.Name+"="+value)
+	}
+	for _, w := range c.Words {
+		fields, err := expand.Fields(w.Raw, s)
+		if err != nil {
+			return cmd, err
+		}
+		cmd.Args = append(cmd.Args, fields...)
+	}
+	var err error
+	cmd.Redirects, err = s.redirects(c.Redirects)
+	return cmd, err
+}
+
+// redirects раскрывает цели перенаправлений и тела here-document
+// и переводит операторы в перенаправления исполнителя
+func (s *Shell) redirects(rs []parser.Redirect) ([]model.Redirect, error) {
+	var out []model.Redirect
+	for _, r := range rs {
+		fd := r.DefaultFd()
+		switch r.Op {
+		case parser.TokDLess, parser.TokDLessDash:
+			body := r.Body
+			// ограничитель в кавычках отключает подстановки в теле
+			if !strings.ContainsAny(r.Target.Raw, "'\"\\") {
+				var err error
+				if body, err = expand.Heredoc(body, s); err != nil {
+					return nil, err
+				}
+			}
+			out = append(out, model.Redirect{Fd: fd, Kind: model.RedirData, Data: body})
+			continue
+		case parser.TokTLess:
+			word, err := expand.Literal(r.Target.Raw, s)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, model.Redirect{Fd: fd, Kind: model.RedirData, Data: word + "\n"})
+			continue
+		}
+
+		target, err := expand.Literal(r.Target.Raw, s)
+		if err != nil {
+			return nil, err
+		}
+		if target == "" {
+			return nil, fmt.Errorf("%s: ambiguous redirect", r.Target.Raw)
+		}
+		switch r.Op {
+		case parser.TokLess:
+			out = append(out, model.Redirect{Fd: fd, Kind: model.RedirIn, Target: target})
+		case parser.TokGreat:
+			out = append(out, model.Redirect{Fd: fd, Kind: model.RedirOut, Target: target})
+		case parser.TokClobber:
+			out = append(out, model.Redirect{Fd: fd, Kind: model.RedirClobber, Target: target})
+		case parser.TokDGreat:
+			out = append(out, model.Redirect{Fd: fd, Kind: model.RedirAppend, Target: target})
+		case parser.TokLessGreat:
+			out = append(out, model.Redirect{Fd: fd, Kind: model.RedirReadWrite, Target: target})
+		case parser.TokAndGreat, parser.TokAndDGreat:
+			kind := model.RedirOut
+			if r.Op == parser.TokAndDGreat {
+				kind = model.RedirAppend
+			}
+			out = append(out,
+				model.Redirect{Fd: 1, Kind: kind, Target: target},
+				model.Redirect{Fd: 2, Kind: model.RedirDup, DupFd: 1})
+		case parser.TokLessAnd, parser.TokGreatAnd:
+			if target == "-" {
+				out = append(out, model.Redirect{Fd: fd, Kind: model.RedirClose})
+				continue
+			}
+			n, err := strconv.Atoi(target)
+			if err != nil || n < 0 {
+				// >&file без номера дескриптора равносильно &>file
+				if r.Op == parser.TokGreatAnd && r.Fd < 0 {
+					out = append(out,
+						model.Redirect{Fd: 1, Kind: model.RedirOut, Target: target},
+						model.Redirect{Fd: 2, Kind: model.RedirDup, DupFd: 1})
+					continue
+				}
+				return nil, fmt.Errorf("%s: ambiguous redirect", r.Target.Raw)
+			}
+			out = append(out, model.Redirect{Fd: fd, Kind: model.RedirDup, DupFd: n})
+		}
+	}
+	return out, nil
+}
+
+// pipelineStatus возвращает код последней стадии, а с pipefail —
+// код самой правой стадии, завершившейся с ошибкой
+func pipelineStatus(statuses []int, pipefail bool) int {
+	if len(statuses) == 0 {
+		return 0
+	}
+	if pipefail {
+		for i := len(statuses) - 1; i >= 0; i-- {
+			if statuses[i] != 0 {
+				return statuses[i]
+			}
+		}
+		return 0
+	}
+	return statuses[len(statuses)-1]
+}
@@ -0,0 +1,129 @@
+package interp
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"wb-l2/internal/executor"
+	"wb-l2/internal/model"
+	"wb-l2/internal/parser"
+)
+
+// maxFuncDepth ограничивает вложенность вызовов функций, чтобы бесконечная
+// рекурсия завершалась ошибкой, а не переполнением стека
+const maxFuncDepth = 1000
+
+func (s *Shell) Aliases() map[string]string {
+	return s.aliases
+}
+
+// Functions возвращает тексты определений функций
+func (s *Shell) Functions() map[string]string {
+	defs := make(map[string]string, len(s.funcs))
+	for name, fn := range s.funcs {
+		defs[name] = fn.Text
+	}
+	return defs
+}
+
+func (s *Shell) UnsetFunc(name string) {
+	delete(s.funcs, name)
+}
+
+// Local запоминает значение переменной в кадре выполняемой функции,
+// если оно ещё не запомнено
+func (s *Shell) Local(name string) error {
+	if len(s.frames) == 0 {
+		return errors.New("local: can only be used in a function")
+	}
+	s.saveVar(s.frames[len(s.frames)-1], name)
+	return nil
+}
+
+func (s *Shell) Return() error {
+	if len(s.frames) == 0 && s.sourceDepth == 0 {
+		return errors.New("return: can only `return' from a function or sourced script")
+	}
+	s.returning = true
+	return nil
+}
+
+// saveVar запоминает переменную в кадре: копию или nil, если её нет
+func (s *Shell) saveVar(frame map[string]*variable, name string) {
+	if _, ok := frame[name]; ok {
+		return
+	}
+	if v, ok := s.vars[name]; ok {
+		copied := *v
+		frame[name] = &copied
+		return
+	}
+	frame[name] = nil
+}
+
+// restoreVar возвращает переменной сохранённые значение и признак экспорта
+func (s *Shell) restoreVar(name string, saved *variable) {
+	s.UnsetVar(name)
+	if saved == nil {
+		return
+	}
+	s.vars[name] = &variable{value: saved.value}
+	if saved.exported {
+		s.Export(name, true)
+	}
+}
+
+// callFunction выполняет функцию в текущем шелле. Аргументы становятся
+// позиционными параметрами, префиксы присваиваний экспортируются на время
+// вызова, а переменные local восстанавливаются после него
+func (s *Shell) callFunction(fn *parser.FuncDef, cmd model.Command, stdio executor.Stdio) {
+	if len(s.frames) >= maxFuncDepth {
+		fmt.Fprintf(s.stdio.Err, "mini-sh: %s: maximum function nesting level exceeded (%d)\n", fn.Name, maxFuncDepth)
+		s.status = 1
+		return
+	}
+	stdio, closeFiles, err := executor.Redirect(stdio, cmd.Redirects, s.dir, s.options["noclobber"])
+	if err != nil {
+		fmt.Fprintf(s.stdio.Err, "mini-sh: %v\n", err)
+		s.status = 1
+		return
+	}
+	defer closeFiles()
+
+	frame := make(map[string]*variable)
+	s.frames = append(s.frames, frame)
+	for _, kv := range cmd.Env {
+		name, value, _ := strings.Cut(kv, "=")
+		s.saveVar(frame, name)
+		s.SetVar(name, value)
+		s.Export(name, true)
+	}
+	// break и continue в функции не действуют на циклы вызывающего кода
+	savedArgs, savedLoops := s.args, s.loopDepth
+	s.args, s.loopDepth = cmd.Args[1:], 0
+
+	s.runCompound(fn.Body, stdio)
+
+	s.returning = false
+	s.args, s.loopDepth = savedArgs, savedLoops
+	s.frames = s.frames[:len(s.frames)-1]
+	for name, v := range frame {
+		s.restoreVar(name, v)
+	}
+}
+
+// definitions возвращает определения псевдонимов и функций в виде команд
+// для начала скрипта дочернего шелла
+func (s *Shell) definitions() string {
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(s.aliases)) {
+		fmt.Fprintf(&b, "alias %s=%s\n", name, parser.Quote(s.aliases[name]))
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.funcs)) {
+		b.WriteString(s.funcs[name].Text + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package interp
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"wb-l2/internal/builtins"
+)
+
+func (s *Shell) Hashed() map[string]*builtins.HashEntry {
+	return s.hash
+}
+
+// LookPath возвращает путь к исполняемому файлу команды. Имя с косой чертой
+// используется как есть, остальные ищутся в $PATH один раз и запоминаются;
+// запомненный путь, который перестал существовать, ищется заново
+func (s *Shell) LookPath(name string) (string, error) {
+	if strings.Contains(name, "/") {
+		return name, nil
+	}
+	if e, ok := s.hash[name]; ok {
+		if builtins.IsExecutable(e.Path) {
+			e.Hits++
+			return e.Path, nil
+		}
+		delete(s.hash, name)
+	}
+	path, _ := s.Var("PATH")
+	found := builtins.SearchPath(name, path, s.dir, false)
+	if len(found) == 0 {
+		return "", exec.ErrNotFound
+	}
+	// пути из относительных каталогов $PATH зависят от текущего каталога
+	if filepath.IsAbs(found[0]) {
+		s.hash[name] = &builtins.HashEntry{Path: found[0], Hits: 1}
+	}
+	return found[0], nil
+}
@@ -0,0 +1,563 @@
+// Package interp выполняет AST mini-sh: списки, пайплайны и управляющие конструкции
+package interp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"wb-l2/internal/builtins"
+	"wb-l2/internal/executor"
+	"wb-l2/internal/jobs"
+	"wb-l2/internal/lineedit"
+	"wb-l2/internal/parser"
+)
+
+// Shell хранит состояние интерпретатора и реализует builtins.Shell
+type Shell struct {
+	jobs *jobs.Table
+	// sigCh получает SIGINT, trapCh — сигналы с ловушками, ignoreCh — сигналы,
+	// которые интерактивный шелл перехватывает, чтобы не реагировать на них
+	sigCh          chan os.Signal
+	trapCh         chan os.Signal
+	ignoreCh       chan os.Signal
+	ignoredAtStart map[os.Signal]bool
+	interactive    bool
+	// signals выставляется, когда шелл сам обрабатывает сигналы процесса
+	signals bool
+	// self — путь к исполняемому файлу шелла для запуска подоболочек;
+	// без него подоболочки выполняются в копии шелла внутри процесса
+	self string
+	// stdio — стандартные потоки шелла, dir — текущий каталог. Каталог процесса
+	// не меняется: внешние команды получают dir при запуске
+	stdio executor.Stdio
+	dir   string
+	// pid — значение $$: PID шелла, а в подоболочке — PID родительского шелла
+	pid int
+	// ctx — контекст выполняемого Run; его отмена прерывает команды как SIGINT
+	ctx context.Context
+
+	status     int
+	pipeStatus []int
+	options    map[string]bool
+	// substStatus — код последней подстановки команды; его получает $?
+	// после команды, состоящей только из присваиваний
+	substStatus int
+	// procSubsts — подстановки процессов выполняемой команды; их каналы
+	// закрываются, когда она завершится
+	procSubsts []*procSubst
+
+	// name и args — $0 и позиционные параметры $1, $2, ...
+	name string
+	args []string
+	vars map[string]*variable
+
+	// exiting выставляется builtin exit; шелл завершается после текущей команды
+	exiting  bool
+	exitCode int
+
+	// loopDepth — вложенность выполняемых циклов; breakN и contN — сколько
+	// циклов осталось прервать командами break и continue
+	loopDepth int
+	breakN    int
+	contN     int
+	// interrupted выставляется, когда команду переднего плана прервал Ctrl+C;
+	// оставшаяся часть строки не выполняется
+	interrupted bool
+
+	// editor читает строки в интерактивном режиме, history — введённые команды
+	editor  *lineedit.Editor
+	history *lineedit.History
+
+	// forked выставляется у копии шелла для встроенной команды в пайплайне
+	// и подоболочки: она не меняет обработку сигналов процесса
+	forked bool
+
+	aliases map[string]string
+	funcs   map[string]*parser.FuncDef
+	// hash — запомненные пути внешних команд; очищается при изменении PATH
+	hash map[string]*builtins.HashEntry
+	// frames — кадры выполняемых функций с прежними значениями локальных переменных
+	frames []map[string]*variable
+	// returning выставляется builtin return; sourceDepth — вложенность source
+	returning   bool
+	sourceDepth int
+	// dirs — стек каталогов pushd и popd без текущего каталога
+	dirs []string
+	// traps — действия ловушек; inTrap выставляется, пока выполняется ловушка.
+	// condDepth — вложенность условий if, while и until, в которых не срабатывает ERR
+	traps     map[string]string
+	inTrap    bool
+	condDepth int
+}
+
+// Config — параметры нового интерпретатора
+type Config struct {
+	// Interactive включает редактор строки, приглашение и управление заданиями
+	Interactive bool
+	// Name и Args — $0 и позиционные параметры
+	Name string
+	Args []string
+	// Stdin, Stdout и Stderr — потоки шелла, по умолчанию потоки процесса
+	Stdin, Stdout, Stderr *os.File
+	// Env — начальное окружение NAME=value, nil — окружение процесса
+	Env []string
+	// Dir — начальный текущий каталог, по умолчанию каталог процесса
+	Dir string
+	// Self — исполняемый файл mini-sh для подоболочек. Без него подоболочки
+	// выполняются в копии шелла внутри процесса
+	Self string
+	// Signals включает обработку сигналов процессом: SIGINT прерывает команды,
+	// а trap перехватывает сигналы
+	Signals bool
+}
+
+// New создаёт интерпретатор. В интерактивном режиме включается управление заданиями,
+// а Ctrl+C прерывает текущую команду, не завершая шелл
+func New(cfg Config) *Shell {
+	s := &Shell{
+		jobs:        jobs.NewTable(cfg.Interactive),
+		sigCh:       make(chan os.Signal, 1),
+		trapCh:      make(chan os.Signal, 16),
+		ignoreCh:    make(chan os.Signal, 1),
+		interactive: cfg.Interactive,
+		signals:     cfg.Signals,
+		self:        cfg.Self,
+		stdio:       executor.StdStreams(),
+		ctx:         context.Background(),
+		options:     map[string]bool{"pipefail": false, "noclobber": false},
+		name:        cfg.Name,
+		args:        cfg.Args,
+		vars:        make(map[string]*variable),
+		aliases:     make(map[string]string),
+		funcs:       make(map[string]*parser.FuncDef),
+		hash:        make(map[string]*builtins.HashEntry),
+		traps:       make(map[string]string),
+	}
+	s.SetStreams(cfg.Stdin, cfg.Stdout, cfg.Stderr)
+	env := cfg.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	s.pid = os.Getpid()
+	var preamble string
+	status := 0
+	for _, kv := range env {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case parentPidVar:
+			if pid, err := strconv.Atoi(v); err == nil {
+				s.pid = pid
+			}
+			continue
+		case statusVar:
+			status, _ = strconv.Atoi(v)
+			continue
+		case dirsVar:
+			s.dirs = strings.Split(v, "\n")
+			continue
+		case preambleVar:
+			preamble = v
+			continue
+		}
+		s.vars[k] = &variable{value: v, exported: true}
+	}
+	if _, ok := s.vars["IFS"]; !ok {
+		s.vars["IFS"] = &variable{value: " \t\n"}
+	}
+	// os.Getwd берёт $PWD из окружения, если он указывает на текущий каталог,
+	// поэтому логический путь с символьными ссылками сохраняется
+	s.dir, _ = os.Getwd()
+	switch {
+	case filepath.IsAbs(cfg.Dir):
+		s.dir = filepath.Clean(cfg.Dir)
+	case cfg.Dir != "":
+		s.dir = filepath.Join(s.dir, cfg.Dir)
+	}
+	s.vars["PWD"] = &variable{value: s.dir, exported: true}
+	s.history = lineedit.NewHistory("", 0)
+	s.ignoredAtStart = make(map[os.Signal]bool)
+	for n := 1; n < 32; n++ {
+		if sig := syscall.Signal(n); signal.Ignored(sig) {
+			s.ignoredAtStart[sig] = true
+		}
+	}
+	if s.signals {
+		s.defaultSignals(append([]os.Signal{os.Interrupt}, shellIgnored...)...)
+	}
+	if preamble != "" {
+		// состояние родительского шелла в подоболочке; команды преамбулы
+		// не должны менять $?, который видит скрипт
+		s.RunReader(strings.NewReader(preamble), "", false)
+	}
+	s.status = status
+	if s.interactive {
+		// rc-файл выполняется до загрузки истории, чтобы в нём можно было задать HISTFILE и HISTSIZE
+		s.sourceRC()
+		s.history = lineedit.NewHistory(s.historyFile(), s.historySize())
+		s.editor = lineedit.New(s.stdio.In, s.stdio.Out, s.history, s.complete)
+	}
+	return s
+}
+
+// SetStreams заменяет стандартные потоки шелла; nil оставляет поток прежним
+func (s *Shell) SetStreams(in, out, errOut *os.File) {
+	if in != nil {
+		s.stdio.In = in
+	}
+	if out != nil {
+		s.stdio.Out = out
+	}
+	if errOut != nil {
+		s.stdio.Err = errOut
+	}
+}
+
+// sourceRC выполняет ~/.minishrc, если он есть
+func (s *Shell) sourceRC() {
+	home, ok := s.Var("HOME")
+	if !ok || home == "" {
+		return
+	}
+	path := filepath.Join(home, ".minishrc")
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	s.Source(path, nil)
+}
+
+func (s *Shell) Jobs() *jobs.Table {
+	return s.jobs
+}
+
+func (s *Shell) Status() int {
+	return s.status
+}
+
+func (s *Shell) Exit(status int) {
+	s.exiting = true
+	s.exitCode = status
+}
+
+// Exiting сообщает, был ли выполнен exit
+func (s *Shell) Exiting() bool {
+	return s.exiting
+}
+
+// ExitCode возвращает код, с которым должен завершиться процесс шелла
+func (s *Shell) ExitCode() int {
+	if s.exiting {
+		return s.exitCode
+	}
+	return s.status
+}
+
+func (s *Shell) History() *lineedit.History {
+	return s.history
+}
+
+// historyFile возвращает файл истории: $HISTFILE или ~/.mini_sh_history
+func (s *Shell) historyFile() string {
+	if path, ok := s.Var("HISTFILE"); ok {
+		return path
+	}
+	home, ok := s.Var("HOME")
+	if !ok || home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".mini_sh_history")
+}
+
+// historySize возвращает наибольшую длину истории: $HISTSIZE или 1000
+func (s *Shell) historySize() int {
+	if v, ok := s.Var("HISTSIZE"); ok {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			return n
+		}
+	}
+	return 1000
+}
+
+// Fork возвращает копию шелла с собственными переменными, параметрами и опциями
+func (s *Shell) Fork() builtins.Shell {
+	f := *s
+	f.forked = true
+	// каналы подстановок принадлежат шеллу, который их открыл
+	f.procSubsts = nil
+	f.vars = make(map[string]*variable, len(s.vars))
+	for name, v := range s.vars {
+		copied := *v
+		f.vars[name] = &copied
+	}
+	f.args = slices.Clone(s.args)
+	f.dirs = slices.Clone(s.dirs)
+	f.options = maps.Clone(s.options)
+	f.traps = maps.Clone(s.traps)
+	f.aliases = maps.Clone(s.aliases)
+	f.funcs = maps.Clone(s.funcs)
+	f.hash = make(map[string]*builtins.HashEntry, len(s.hash))
+	for name, e := range s.hash {
+		copied := *e
+		f.hash[name] = &copied
+	}
+	f.frames = make([]map[string]*variable, len(s.frames))
+	for i, frame := range s.frames {
+		f.frames[i] = maps.Clone(frame)
+	}
+	return &f
+}
+
+func (s *Shell) Dir() string {
+	return s.dir
+}
+
+// abs возвращает путь относительно текущего каталога шелла
+func (s *Shell) abs(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.dir, path)
+}
+
+// Chdir меняет текущий каталог шелла, если в каталог можно перейти.
+// Относительный путь отсчитывается от текущего каталога
+func (s *Shell) Chdir(dir string) error {
+	dir = s.abs(dir)
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return &os.PathError{Op: "chdir", Path: dir, Err: errors.Unwrap(err)}
+	}
+	if !fi.IsDir() {
+		return &os.PathError{Op: "chdir", Path: dir, Err: syscall.ENOTDIR}
+	}
+	if err := syscall.Access(dir, 1); err != nil {
+		return &os.PathError{Op: "chdir", Path: dir, Err: err}
+	}
+	s.dir = filepath.Clean(dir)
+	return nil
+}
+
+func (s *Shell) Dirs() []string {
+	return s.dirs
+}
+
+func (s *Shell) SetDirs(dirs []string) {
+	s.dirs = dirs
+}
+
+func (s *Shell) Options() map[string]bool {
+	return s.options
+}
+
+func (s *Shell) Args() []string {
+	return s.args
+}
+
+func (s *Shell) SetArgs(args []string) {
+	s.args = args
+}
+
+func (s *Shell) Break(n int) error {
+	if s.loopDepth == 0 {
+		return fmt.Errorf("break: only meaningful in a `for', `while', or `until' loop")
+	}
+	s.breakN = min(n, s.loopDepth)
+	return nil
+}
+
+func (s *Shell) Continue(n int) error {
+	if s.loopDepth == 0 {
+		return fmt.Errorf("continue: only meaningful in a `for', `while', or `until' loop")
+	}
+	s.contN = min(n, s.loopDepth)
+	return nil
+}
+
+// RunReader читает и выполняет команды до конца ввода или до exit.
+// Незавершённая конструкция (незакрытая кавычка, висящий | или &&, if без fi)
+// дочитывается следующими строками. source используется в сообщениях об ошибках
+func (s *Shell) RunReader(r io.Reader, source string, interactive bool) {
+	reader := bufio.NewReader(r)
+	lineNo := 0
+	for !s.exiting && !s.returning {
+		if s.ctx.Err() != nil {
+			s.Exit(128 + int(syscall.SIGINT))
+			return
+		}
+		s.runTraps()
+		s.jobs.Reap()
+		if interactive {
+			for _, line := range s.jobs.Notifications() {
+				fmt.Fprintln(s.stdio.Err, line)
+			}
+		}
+
+		var (
+			buf         string
+			list        *parser.List
+			perr        error
+			start       = lineNo + 1
+			prompt      string
+			interrupted bool
+		)
+		if interactive {
+			prompt = s.prompt("PS1")
+		}
+		for {
+			line, err := s.readLine(reader, prompt, interactive)
+			if errors.Is(err, lineedit.ErrInterrupted) {
+				// Ctrl+C в редакторе отменяет набранную команду целиком
+				interrupted = true
+				break
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				fmt.Fprintf(s.stdio.Err, "mini-sh: read error: %v\n", err)
+				return
+			}
+			if line == "" && err != nil {
+				if buf == "" {
+					return
+				}
+				// ввод закончился посреди конструкции
+				s.syntaxError(source, start, perr, interactive)
+				return
+			}
+			lineNo++
+			buf += line
+			list, perr = parser.ParseAliases(buf, s.aliases)
+			if !errors.Is(perr, parser.ErrIncomplete) {
+				break
+			}
+			if interactive {
+				// незакрытая кавычка, висящий | или && и незавершённая конструкция
+				prompt = s.prompt("PS2")
+			}
+		}
+		if interactive && s.editor != nil && !interrupted {
+			// команда из нескольких строк попадает в историю одной записью
+			s.history.Add(historyEntry(buf))
+		}
+		if interrupted {
+			s.status = 128 + int(syscall.SIGINT)
+			s.runTrap("INT")
+			continue
+		}
+		if perr != nil {
+			s.syntaxError(source, start, perr, interactive)
+			if !interactive {
+				return
+			}
+			continue
+		}
+		s.interrupted = false
+		s.runList(list, s.stdio)
+		if s.interrupted && interactive {
+			// после ^C, выведенного терминалом, приглашение начинается с новой строки
+			fmt.Fprintln(s.stdio.Out)
+		}
+		if s.interrupted && !s.interactive {
+			// скрипт, прерванный Ctrl+C, завершается, как и убитая им команда
+			s.Exit(128 + int(syscall.SIGINT))
+		}
+	}
+}
+
+// Run выполняет скрипт, как mini-sh -c, в текущем состоянии шелла
+// и возвращает код завершения. Переменные, функции и текущий каталог
+// сохраняются до следующего вызова. Отмена ctx прерывает выполнение,
+// как SIGINT: процессам посылается сигнал, а скрипт завершается с кодом 130
+func (s *Shell) Run(ctx context.Context, script string) int {
+	s.ctx = ctx
+	defer func() { s.ctx = context.Background() }()
+	s.exiting, s.interrupted = false, false
+	s.RunReader(strings.NewReader(script), "", false)
+	s.RunExitTrap()
+	return s.ExitCode()
+}
+
+// readLine читает очередную строку ввода вместе с переводом строки.
+// В интерактивном режиме строку читает редактор с приглашением prompt
+func (s *Shell) readLine(reader *bufio.Reader, prompt string, interactive bool) (string, error) {
+	if !interactive || s.editor == nil {
+		if interactive {
+			fmt.Fprint(s.stdio.Out, prompt)
+		}
+		return reader.ReadString('\n')
+	}
+	line, err := s.editor.ReadLine(prompt)
+	if err != nil {
+		return "", err
+	}
+	return line + "\n", nil
+}
+
+// historyEntry готовит введённую команду для истории: продолжения строк
+// через \ склеиваются, как их склеивает разбор, остальные переводы строк
+// сохраняются
+func historyEntry(buf string) string {
+	lines := strings.Split(strings.TrimSuffix(buf, "\n"), "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if i == len(lines)-1 {
+			b.WriteString(line)
+			break
+		}
+		if n := len(line) - len(strings.TrimRight(line, `\`)); n%2 == 1 {
+			b.WriteString(line[:len(line)-1])
+			continue
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// syntaxError печатает ошибку разбора; в скрипте она завершает выполнение с кодом 2
+func (s *Shell) syntaxError(source string, line int, err error, interactive bool) {
+	s.status = 2
+	if interactive || source == "" {
+		fmt.Fprintf(s.stdio.Err, "mini-sh: %v\n", err)
+		return
+	}
+	fmt.Fprintf(s.stdio.Err, "mini-sh: %s: line %d: %v\n", source, line, err)
+}
+
+// Source выполняет файл в текущем шелле. Если переданы аргументы,
+// они временно заменяют позиционные параметры. return завершает файл
+func (s *Shell) Source(path string, args []string) int {
+	f, err := os.Open(s.abs(path))
+	if err != nil {
+		var pathErr *os.PathError
+		if errors.As(err, &pathErr) {
+			// в сообщении остаётся путь в том виде, как его передали
+			pathErr.Path = path
+		}
+		fmt.Fprintf(s.stdio.Err, "mini-sh: %v\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	if len(args) > 0 {
+		saved := s.args
+		s.args = args
+		defer func() { s.args = saved }()
+	}
+	s.status = 0
+	s.sourceDepth++
+	s.RunReader(f, path, false)
+	s.sourceDepth--
+	s.returning = false
+	return s.status
+}
@@ -0,0 +1,115 @@
+package interp
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"syscall"
+
+	"wb-l2/internal/executor"
+	"wb-l2/internal/parser"
+)
+
+// procSubstFd — наименьший номер дескриптора подстановки процесса. Как в bash,
+// номера берутся высокие, чтобы не пересекаться с дескрипторами,
+// которые скрипты открывают перенаправлениями
+const procSubstFd = 63
+
+// procSubst — подстановка процесса <(...) или >(...): конец канала, открытый
+// в шелле под номером fd из пути /dev/fd/N, и завершение её команды
+type procSubst struct {
+	file   *os.File
+	fd     int
+	output bool
+	done   <-chan int
+}
+
+// ProcSubst запускает скрипт подстановки процесса в подоболочке и возвращает
+// путь /dev/fd/N к каналу, соединённому с его выводом или, при output, вводом.
+// Дескриптор N остаётся открытым в шелле и передаётся всем запускаемым
+// командам, пока не закончится команда, в которой встретилась подстановка
+func (s *Shell) ProcSubst(script string, output bool) (string, error) {
+	if _, err := parser.ParseAliases(script, s.aliases); err != nil {
+		return "", err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	mine, theirs := r, w
+	if output {
+		mine, theirs = w, r
+	}
+	fds := append([]*os.File{s.stdio.In, s.stdio.Out, s.stdio.Err}, s.stdio.Extra...)
+	if output {
+		fds[0] = theirs
+	} else {
+		fds[1] = theirs
+	}
+	done, err := executor.Start(s, s.subshell(script), fds, []*os.File{theirs})
+	if err != nil {
+		mine.Close()
+		return "", err
+	}
+
+	// канал переносится на высокий номер; в дочерние процессы он попадает
+	// только через таблицу дескрипторов команды
+	fd, _, errno := syscall.Syscall(syscall.SYS_FCNTL, mine.Fd(), syscall.F_DUPFD_CLOEXEC, procSubstFd)
+	mine.Close()
+	if errno != 0 {
+		return "", os.NewSyscallError("fcntl", errno)
+	}
+	path := fmt.Sprintf("/dev/fd/%d", fd)
+	s.procSubsts = append(s.procSubsts, &procSubst{file: os.NewFile(fd, path), fd: int(fd), output: output, done: done})
+	return path, nil
+}
+
+// withProcSubsts добавляет каналы открытых подстановок процессов в таблицу
+// дескрипторов команды. Перенаправления команды применяются позже и могут их заменить
+func (s *Shell) withProcSubsts(stdio executor.Stdio) executor.Stdio {
+	if len(s.procSubsts) == 0 {
+		return stdio
+	}
+	extra := slices.Clone(stdio.Extra)
+	for _, p := range s.procSubsts {
+		n := p.fd - 3
+		for len(extra) <= n {
+			extra = append(extra, nil)
+		}
+		if extra[n] == nil {
+			extra[n] = p.file
+		}
+	}
+	stdio.Extra = extra
+	return stdio
+}
+
+// closeProcSubsts закрывает каналы подстановок, открытых после первых n.
+// При wait шелл дожидается команд >(...), чтобы их вывод был готов
+// к следующей команде; отмена контекста Run прекращает ожидание.
+// Команды <(...) не ждут: закрытый канал завершит их по SIGPIPE.
+// Остановленное задание держит каналы открытыми, поэтому после него не ждут
+func (s *Shell) closeProcSubsts(n int, wait bool) {
+	for _, p := range s.procSubsts[n:] {
+		p.file.Close()
+		if p.output && wait {
+			select {
+			case <-p.done:
+			case <-s.ctx.Done():
+			}
+		}
+	}
+	s.procSubsts = s.procSubsts[:n]
+}
+
+// stopped сообщает, что пайплайн остановлен, а не завершён: у остановленных
+// процессов код 128 плюс номер сигнала остановки
+func stopped(statuses []int) bool {
+	return slices.ContainsFunc(statuses, func(code int) bool {
+		switch syscall.Signal(code - 128) {
+		case syscall.SIGTSTP, syscall.SIGSTOP, syscall.SIGTTIN, syscall.SIGTTOU:
+			return true
+		}
+		return false
+	})
+}
@@ -0,0 +1,194 @@
+package interp
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"wb-l2/internal/expand"
+)
+
+// Приглашения по умолчанию, если PS1 и PS2 не заданы
+const (
+	defaultPS1 = `mini-sh:\W\$ `
+	defaultPS2 = "> "
+)
+
+// prompt строит приглашение из переменной name (PS1 или PS2): раскрывает
+// экранированные последовательности, затем выполняет подстановки $VAR,
+// $(...) и $((...)), как bash:
+//
+//	\u пользователь, \h и \H имя хоста, \w и \W текущий каталог,
+//	\g ветка git, \? код последней команды, \$ # для root и $ для остальных,
+//	\t \T \@ \A \d время и дата, \j число заданий, \! номер в истории,
+//	\s имя шелла, \n \e \a \\ символы, \[ \] границы непечатаемой части
+func (s *Shell) prompt(name string) string {
+	ps, ok := s.Var(name)
+	if !ok {
+		ps = defaultPS1
+		if name == "PS2" {
+			ps = defaultPS2
+		}
+	}
+	// подстановки в приглашении не меняют $?
+	status := s.status
+	text, err := expand.Literal(s.decodePrompt(ps), s)
+	s.status = status
+	if err != nil {
+		// ошибка подстановки не должна мешать вводу команды
+		fmt.Fprintf(s.stdio.Err, "mini-sh: %s: %v\n", name, err)
+		return ps
+	}
+	return text
+}
+
+// decodePrompt заменяет экранированные последовательности приглашения.
+// Подставленные значения экранируются, чтобы последующее раскрытие
+// оставило их как есть
+func (s *Shell) decodePrompt(ps string) string {
+	var b strings.Builder
+	now := time.Now()
+	for i := 0; i < len(ps); i++ {
+		if ps[i] != '\\' || i+1 == len(ps) {
+			b.WriteByte(ps[i])
+			continue
+		}
+		i++
+		value := ""
+		switch c := ps[i]; c {
+		case 'u':
+			value = s.userName()
+		case 'h', 'H':
+			value, _ = os.Hostname()
+			if c == 'h' {
+				value, _, _ = strings.Cut(value, ".")
+			}
+		case 'w', 'W':
+			value = s.promptDir(c == 'W')
+		case 'g':
+			value = gitBranch(s.dir)
+		case '?':
+			value = strconv.Itoa(s.status)
+		case '$':
+			value = "$"
+			if os.Geteuid() == 0 {
+				value = "#"
+			}
+		case 't':
+			value = now.Format("15:04:05")
+		case 'T':
+			value = now.Format("03:04:05")
+		case '@':
+			value = now.Format("03:04 PM")
+		case 'A':
+			value = now.Format("15:04")
+		case 'd':
+			value = now.Format("Mon Jan 02")
+		case 'j':
+			value = strconv.Itoa(len(s.jobs.List()))
+		case '!':
+			value = strconv.Itoa(len(s.history.Entries()) + 1)
+		case 's':
+			value = "mini-sh"
+		case 'n':
+			value = "\n"
+		case 'e':
+			value = "\033"
+		case 'a':
+			value = "\a"
+		case '[':
+			value = "\001"
+		case ']':
+			value = "\002"
+		case '\\':
+			value = `\`
+		default:
+			// неизвестная последовательность остаётся как есть
+			b.WriteString(ps[i-1 : i+1])
+			continue
+		}
+		for _, r := range value {
+			if strings.ContainsRune("$`\\\"'~", r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func (s *Shell) userName() string {
+	if name, ok := s.Var("USER"); ok && name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// promptDir возвращает текущий каталог, домашний каталог сокращается до ~.
+// При base возвращается только последний элемент пути
+func (s *Shell) promptDir(base bool) string {
+	cwd := s.dir
+	home, _ := s.Var("HOME")
+	if home != "" && home != "/" {
+		if cwd == home {
+			return "~"
+		}
+		if rest, ok := strings.CutPrefix(cwd, home+"/"); ok && !base {
+			return "~/" + rest
+		}
+	}
+	if base {
+		return filepath.Base(cwd)
+	}
+	return cwd
+}
+
+// gitBranch ищет репозиторий git от текущего каталога вверх и возвращает
+// ветку из .git/HEAD, а для отсоединённого HEAD — сокращённый хеш.
+// Вне репозитория возвращается пустая строка
+func gitBranch(dir string) string {
+	for {
+		gitDir := filepath.Join(dir, ".git")
+		if fi, err := os.Stat(gitDir); err == nil {
+			if !fi.IsDir() {
+				// рабочее дерево или подмодуль: .git содержит "gitdir: путь"
+				data, err := os.ReadFile(gitDir)
+				if err != nil {
+					return ""
+				}
+				path, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+				if !ok {
+					return ""
+				}
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(dir, path)
+				}
+				gitDir = path
+			}
+			head, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+			if err != nil {
+				return ""
+			}
+			ref := strings.TrimSpace(string(head))
+			if branch, ok := strings.CutPrefix(ref, "ref: refs/heads/"); ok {
+				return branch
+			}
+			if len(ref) > 7 {
+				return ref[:7]
+			}
+			return ref
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,77 @@
+package interp
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// shellEnv в окружении запускает тестовый бинарник как mini-sh -c,
+// чтобы подоболочки выполнялись в дочерних процессах
+const shellEnv = "MINI_SH_TEST_SHELL"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(shellEnv) != "" && len(os.Args) > 2 && os.Args[1] == "-c" {
+		sh := New(Config{Name: "mini-sh", Args: os.Args[3:], Self: os.Args[0], Signals: true})
+		os.Exit(sh.Run(context.Background(), os.Args[2]))
+	}
+	os.Exit(m.Run())
+}
+
+// runInProcess выполняет скрипт с подоболочками в копиях шелла внутри процесса
+func runInProcess(t *testing.T, script string) string {
+	t.Helper()
+	out, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	sh := New(Config{Name: "mini-sh", Stdout: out, Stderr: out})
+	sh.Run(context.Background(), script)
+	data, err := os.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// runExec выполняет скрипт с подоболочками в дочерних процессах шелла
+func runExec(t *testing.T, script string) string {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-c", script)
+	cmd.Env = append(os.Environ(), shellEnv+"=1")
+	data, _ := cmd.CombinedOutput()
+	return string(data)
+}
+
+func TestSubshellState(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		script string
+		want   string
+	}{
+		{"false; (echo $?)", "1\n"},
+		{"false; { echo $?; } | cat", "1\n"},
+		{"(exit 3); (echo $?)", "3\n"},
+		{"false; (true; echo $?)", "0\n"},
+		{"pushd / >/dev/null; pushd " + dir + " >/dev/null; (dirs -p | head -2)", dir + "\n/\n"},
+		{"pushd / >/dev/null; (popd >/dev/null; pwd); pwd", dir + "\n/\n"},
+		{"trap '' INT; (trap)", "trap -- '' INT\n"},
+		{"trap 'echo exit' EXIT; trap '' TERM; (trap)", "trap -- '' TERM\nexit\n"},
+		{"x=1; f() { echo f$x; }; (f)", "f1\n"},
+		{"set -o pipefail; (false | true); echo $?", "1\n"},
+		{"a=1; (a=2; echo $a); echo $a", "2\n1\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.script, func(t *testing.T) {
+			script := "cd " + dir + "\n" + tt.script
+			if got := runInProcess(t, script); got != tt.want {
+				t.Errorf("in process: %q = %q, want %q", tt.script, got, tt.want)
+			}
+			if got := runExec(t, script); got != tt.want {
+				t.Errorf("child process: %q = %q, want %q", tt.script, got, tt.want)
+			}
+		})
+	}
+}
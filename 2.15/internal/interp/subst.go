@@ -0,0 +1,82 @@
+package interp
+
+import (
+	"io"
+	"os"
+
+	"wb-l2/internal/builtins"
+	"wb-l2/internal/model"
+	"wb-l2/internal/parser"
+)
+
+// pureBuiltins не меняют состояние шелла, поэтому одиночную такую команду
+// в подстановке можно выполнить в текущем процессе. Встроенные команды
+// в пайплайне и так работают с копией шелла
+var pureBuiltins = map[string]bool{"echo": true, "pwd": true, "test": true, "[": true, ":": true, "true": true, "false": true}
+
+// Subst выполняет скрипт подстановки команды $(...) и возвращает его вывод.
+// Пайплайн из внешних команд запускается напрямую с перехваченным stdout,
+// всё остальное — в дочернем шелле, чтобы cd, exit и присваивания
+// не затрагивали текущий шелл
+func (s *Shell) Subst(script string) (string, error) {
+	list, err := parser.ParseAliases(script, s.aliases)
+	if err != nil {
+		return "", err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	type result struct {
+		out []byte
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		out, err := io.ReadAll(r)
+		r.Close()
+		done <- result{out, err}
+	}()
+
+	defer s.closeProcSubsts(len(s.procSubsts), true)
+	stdio := s.stdio
+	stdio.Out = w
+	cmds, ok := s.substCommands(list)
+	if !ok {
+		cmds = []model.Command{s.subshell(script)}
+	}
+	statuses := s.launch(cmds, script, stdio, false)
+	w.Close()
+	res := <-done
+
+	s.substStatus = pipelineStatus(statuses, s.options["pipefail"])
+	return string(res.out), res.err
+}
+
+// substCommands возвращает стадии подстановки, если её можно выполнить
+// без дочернего шелла
+func (s *Shell) substCommands(list *parser.List) ([]model.Command, bool) {
+	if len(list.Items) != 1 || list.Items[0].Background {
+		return nil, false
+	}
+	andOr := list.Items[0].AndOr
+	if len(andOr.Pipelines) != 1 || andOr.Pipelines[0].Negate {
+		return nil, false
+	}
+	pl := andOr.Pipelines[0]
+	for _, c := range pl.Commands {
+		simple, ok := c.(*parser.SimpleCommand)
+		if !ok || len(simple.Assigns) > 0 {
+			return nil, false
+		}
+	}
+	cmds, ok := s.simpleCommands(pl)
+	if !ok {
+		return nil, false
+	}
+	if len(cmds) == 1 && builtins.IsBuiltin(cmds[0].Args[0]) && !pureBuiltins[cmds[0].Args[0]] {
+		return nil, false
+	}
+	return cmds, true
+}
@@ -0,0 +1,137 @@
+package interp
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"wb-l2/internal/builtins"
+	"wb-l2/internal/parser"
+)
+
+// shellIgnored — сигналы, которые интерактивный шелл не должен получать
+// по умолчанию: управление заданиями, Ctrl+\ и kill без номера сигнала
+var shellIgnored = []os.Signal{syscall.SIGTSTP, syscall.SIGTTIN, syscall.SIGTTOU, syscall.SIGQUIT, syscall.SIGTERM}
+
+func (s *Shell) Traps() map[string]string {
+	return s.traps
+}
+
+func (s *Shell) SetTrap(name, action string) {
+	s.traps[name] = action
+	s.updateSignal(name)
+}
+
+func (s *Shell) ResetTrap(name string) {
+	delete(s.traps, name)
+	s.updateSignal(name)
+}
+
+func (s *Shell) Kill(pid int, sig syscall.Signal) error {
+	if pid == os.Getpid() && sig != 0 && s.traps[builtins.SignalName(sig)] != "" {
+		select {
+		case s.trapCh <- sig:
+		default:
+		}
+		return nil
+	}
+	return syscall.Kill(pid, sig)
+}
+
+// defaultSignals включает обработку сигналов, нужную шеллу без ловушек: SIGINT
+// перехватывается всегда, остальное в интерактивном режиме — только чтобы
+// не завершаться и не останавливаться. Перехваченные, а не игнорируемые
+// сигналы сбрасываются в дочерних процессах на обработчик по умолчанию.
+// Сигналы, игнорируемые при запуске, остаются игнорируемыми, как в POSIX
+func (s *Shell) defaultSignals(sigs ...os.Signal) {
+	for _, sig := range sigs {
+		if s.ignoredAtStart[sig] {
+			continue
+		}
+		if sig == os.Interrupt {
+			signal.Notify(s.sigCh, sig)
+		} else if s.interactive {
+			signal.Notify(s.ignoreCh, sig)
+		}
+	}
+}
+
+// updateSignal приводит обработку сигнала к установленной ловушке.
+// Копия шелла для пайплайна и шелл без обработки сигналов только запоминают
+// ловушку: обработчики сигналов общие для всего процесса
+func (s *Shell) updateSignal(name string) {
+	sig, ok := builtins.ParseSignal(name)
+	if !ok || sig == 0 || !s.signals || s.forked || s.ignoredAtStart[sig] {
+		return
+	}
+	signal.Reset(sig)
+	action, trapped := s.traps[name]
+	if trapped && action == "" {
+		signal.Ignore(sig)
+		return
+	}
+	if trapped {
+		signal.Notify(s.trapCh, sig)
+	}
+	for _, def := range append([]os.Signal{os.Interrupt}, shellIgnored...) {
+		if def == sig {
+			s.defaultSignals(sig)
+		}
+	}
+}
+
+// runTraps выполняет ловушки сигналов, поступивших с прошлой проверки.
+// Сигнал, пришедший во время команды переднего плана, обрабатывается
+// после её завершения
+func (s *Shell) runTraps() {
+	if s.inTrap {
+		return
+	}
+	for {
+		select {
+		case sig := <-s.trapCh:
+			s.runTrap(builtins.SignalName(sig.(syscall.Signal)))
+		default:
+			return
+		}
+	}
+}
+
+// runTrap выполняет действие ловушки name. $? после ловушки
+// остаётся прежним, если в ней не было exit
+func (s *Shell) runTrap(name string) {
+	action := s.traps[name]
+	if action == "" || s.inTrap {
+		return
+	}
+	list, err := parser.ParseAliases(action, s.aliases)
+	if err != nil {
+		fmt.Fprintf(s.stdio.Err, "mini-sh: trap: %v\n", err)
+		return
+	}
+	status, pipeStatus := s.status, s.pipeStatus
+	s.inTrap = true
+	s.runList(list, s.stdio)
+	s.inTrap = false
+	if !s.exiting {
+		s.status, s.pipeStatus = status, pipeStatus
+	}
+}
+
+// RunExitTrap выполняет ловушку EXIT перед завершением шелла.
+// exit внутри ловушки меняет код завершения
+func (s *Shell) RunExitTrap() {
+	if s.traps["EXIT"] == "" {
+		return
+	}
+	code := s.ExitCode()
+	s.exiting, s.returning, s.interrupted = false, false, false
+	s.breakN, s.contN = 0, 0
+	s.status = code
+	s.runTrap("EXIT")
+	delete(s.traps, "EXIT")
+	if !s.exiting {
+		s.Exit(code)
+	}
+}
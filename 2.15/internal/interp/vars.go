@@ -0,0 +1,124 @@
+package interp
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// variable — переменная шелла. Экспортируемые переменные дублируются
+// в окружение процесса и поэтому наследуются запускаемыми командами
+type variable struct {
+	value    string
+	exported bool
+}
+
+// Var возвращает значение специального параметра или переменной шелла
+func (s *Shell) Var(name string) (string, bool) {
+	switch name {
+	case "?":
+		return strconv.Itoa(s.status), true
+	case "$":
+		return strconv.Itoa(s.pid), true
+	case "!":
+		pid := s.jobs.LastBackground()
+		if pid == 0 {
+			return "", false
+		}
+		return strconv.Itoa(pid), true
+	case "0":
+		return s.name, true
+	case "#":
+		return strconv.Itoa(len(s.args)), true
+	case "@", "*":
+		return strings.Join(s.args, " "), len(s.args) > 0
+	case "PIPESTATUS":
+		if len(s.pipeStatus) == 0 {
+			return "", false
+		}
+		return strconv.Itoa(s.pipeStatus[0]), true
+	case "PIPESTATUS[@]", "PIPESTATUS[*]":
+		codes := make([]string, len(s.pipeStatus))
+		for i, code := range s.pipeStatus {
+			codes[i] = strconv.Itoa(code)
+		}
+		return strings.Join(codes, " "), true
+	}
+	if idx, ok := strings.CutPrefix(name, "PIPESTATUS["); ok {
+		n, err := strconv.Atoi(strings.TrimSuffix(idx, "]"))
+		if err != nil || n < 0 || n >= len(s.pipeStatus) {
+			return "", false
+		}
+		return strconv.Itoa(s.pipeStatus[n]), true
+	}
+	if n, err := strconv.Atoi(name); err == nil && n > 0 {
+		if n > len(s.args) {
+			return "", false
+		}
+		return s.args[n-1], true
+	}
+	if v, ok := s.vars[name]; ok {
+		return v.value, true
+	}
+	return "", false
+}
+
+// SetVar присваивает переменную, сохраняя признак экспорта
+func (s *Shell) SetVar(name, value string) {
+	v, ok := s.vars[name]
+	if !ok {
+		v = &variable{}
+		s.vars[name] = v
+	}
+	v.value = value
+	if name == "PATH" {
+		clear(s.hash)
+	}
+}
+
+// UnsetVar удаляет переменную шелла и из окружения
+func (s *Shell) UnsetVar(name string) {
+	delete(s.vars, name)
+	if name == "PATH" {
+		clear(s.hash)
+	}
+}
+
+// Export включает или выключает передачу переменной дочерним процессам.
+// Экспорт несуществующей переменной создаёт её с пустым значением
+func (s *Shell) Export(name string, exported bool) {
+	v, ok := s.vars[name]
+	if !ok {
+		if !exported {
+			return
+		}
+		v = &variable{}
+		s.vars[name] = v
+	}
+	v.exported = exported
+}
+
+// Environ возвращает экспортируемые переменные в формате NAME=value, отсортированные
+// по имени. Окружение процесса шелл не меняет: его получают запускаемые команды
+func (s *Shell) Environ() []string {
+	var env []string
+	for name, v := range s.vars {
+		if v.exported {
+			env = append(env, name+"="+v.value)
+		}
+	}
+	sort.Strings(env)
+	return env
+}
+
+// Locals возвращает неэкспортируемые переменные в формате NAME=value, отсортированные по имени
+func (s *Shell) Locals() []string {
+	var vars []string
+	for name, v := range s.vars {
+		if !v.exported {
+			vars = append(vars, name+"="+v.value)
+		}
+	}
+	sort.Strings(vars)
+	return vars
+}
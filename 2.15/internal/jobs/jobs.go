@@ -0,0 +1,511 @@
+package jobs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// State — состояние задания
+type State int
+
+const (
+	Running State = iota
+	Stopped
+	Done
+)
+
+func (s State) String() string {
+	switch s {
+	case Running:
+		return "Running"
+	case Stopped:
+		return "Stopped"
+	default:
+		return "Done"
+	}
+}
+
+// Process — один процесс пайплайна
+type Process struct {
+	Pid     int
+	Status  syscall.WaitStatus
+	Done    bool
+	Stopped bool
+}
+
+// ExitCode возвращает код завершения процесса, для убитых сигналом — 128+N
+func (p *Process) ExitCode() int {
+	switch {
+	case p.Status.Signaled():
+		return 128 + int(p.Status.Signal())
+	case p.Status.Stopped():
+		return 128 + int(p.Status.StopSignal())
+	default:
+		return p.Status.ExitStatus()
+	}
+}
+
+// SignalText описывает сигнал, убивший процесс, как strsignal в bash:
+// "Killed", "Terminated", "Segmentation fault (core dumped)".
+// Для процесса, завершившегося самостоятельно, возвращается пустая строка
+func (p *Process) SignalText() string {
+	if !p.Done || !p.Status.Signaled() {
+		return ""
+	}
+	text := p.Status.Signal().String()
+	text = strings.ToUpper(text[:1]) + text[1:]
+	if p.Status.CoreDump() {
+		text += " (core dumped)"
+	}
+	return text
+}
+
+// Job — пайплайн, запущенный в собственной группе процессов
+type Job struct {
+	ID    int
+	Pgid  int
+	Text  string
+	Procs []*Process
+
+	notified bool
+}
+
+// State вычисляет состояние задания по состояниям процессов
+func (j *Job) State() State {
+	done := true
+	for _, p := range j.Procs {
+		if p.Stopped {
+			return Stopped
+		}
+		if !p.Done {
+			done = false
+		}
+	}
+	if done {
+		return Done
+	}
+	return Running
+}
+
+// Status возвращает код завершения последнего процесса пайплайна
+func (j *Job) Status() int {
+	if len(j.Procs) == 0 {
+		return 0
+	}
+	return j.Procs[len(j.Procs)-1].ExitCode()
+}
+
+// PipeStatus возвращает коды завершения всех процессов пайплайна
+func (j *Job) PipeStatus() []int {
+	codes := make([]int, len(j.Procs))
+	for i, p := range j.Procs {
+		codes[i] = p.ExitCode()
+	}
+	return codes
+}
+
+// Table — таблица заданий шелла
+type Table struct {
+	mu   sync.Mutex
+	jobs []*Job
+	// current и previous — задания %+ и %-
+	current, previous *Job
+	// lastPid — последний процесс задания, последним запущенного в фоне, для $!
+	lastPid int
+
+	interactive bool
+	tty         int
+	shellPgid   int
+}
+
+// NewTable создаёт таблицу заданий. В интерактивном режиме шелл переводится
+// в собственную группу процессов и забирает терминал
+func NewTable(interactive bool) *Table {
+	t := &Table{
+		interactive: interactive,
+		tty:         int(os.Stdin.Fd()),
+		shellPgid:   syscall.Getpgrp(),
+	}
+	if !interactive {
+		return t
+	}
+
+	// Ждём, пока шелл окажется на переднем плане
+	for {
+		pgid, err := tcgetpgrp(t.tty)
+		if err != nil || pgid == t.shellPgid {
+			break
+		}
+		_ = syscall.Kill(-t.shellPgid, syscall.SIGTTIN)
+	}
+
+	if err := syscall.Setpgid(0, 0); err == nil {
+		t.shellPgid = syscall.Getpid()
+	}
+	_ = tcsetpgrp(t.tty, t.shellPgid)
+	return t
+}
+
+// Interactive сообщает, управляет ли шелл терминалом
+func (t *Table) Interactive() bool {
+	return t.interactive
+}
+
+// TTY возвращает дескриптор управляющего терминала
+func (t *Table) TTY() int {
+	return t.tty
+}
+
+// Add регистрирует запущенный пайплайн
+func (t *Table) Add(pgid int, pids []int, text string) *Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	id := 1
+	for _, j := range t.jobs {
+		if j.ID >= id {
+			id = j.ID + 1
+		}
+	}
+	j := &Job{ID: id, Pgid: pgid, Text: text}
+	for _, pid := range pids {
+		j.Procs = append(j.Procs, &Process{Pid: pid})
+	}
+	t.jobs = append(t.jobs, j)
+	return j
+}
+
+// SetCurrent делает задание текущим (%+)
+func (t *Table) SetCurrent(j *Job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.setCurrent(j)
+}
+
+func (t *Table) setCurrent(j *Job) {
+	if t.current == j {
+		return
+	}
+	t.previous = t.current
+	t.current = j
+}
+
+// SetLastBackground запоминает задание, запущенное или продолженное в фоне:
+// его последний процесс становится значением $!
+func (t *Table) SetLastBackground(j *Job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastPid = j.Procs[len(j.Procs)-1].Pid
+}
+
+// LastBackground возвращает PID для $!; 0 — фоновых заданий ещё не было
+func (t *Table) LastBackground() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastPid
+}
+
+// List возвращает задания в порядке номеров
+func (t *Table) List() []*Job {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*Job(nil), t.jobs...)
+}
+
+// Find ищет задание по спецификации: %N, %+, %%, %-, %строка или PID
+func (t *Table) Find(spec string) (*Job, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if spec == "" || spec == "%" || spec == "%+" || spec == "%%" {
+		if t.current == nil {
+			return nil, fmt.Errorf("%s: no current job", specName(spec))
+		}
+		return t.current, nil
+	}
+	if spec == "%-" {
+		if t.previous == nil {
+			return nil, fmt.Errorf("%s: no such job", spec)
+		}
+		return t.previous, nil
+	}
+
+	if !strings.HasPrefix(spec, "%") {
+		pid, err := strconv.Atoi(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: no such job", spec)
+		}
+		for _, j := range t.jobs {
+			for _, p := range j.Procs {
+				if p.Pid == pid {
+					return j, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%s: no such job", spec)
+	}
+
+	if id, err := strconv.Atoi(spec[1:]); err == nil {
+		for _, j := range t.jobs {
+			if j.ID == id {
+				return j, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: no such job", spec)
+	}
+
+	var found *Job
+	for _, j := range t.jobs {
+		if strings.HasPrefix(j.Text, spec[1:]) {
+			if found != nil {
+				return nil, fmt.Errorf("%s: ambiguous job spec", spec)
+			}
+			found = j
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%s: no such job", spec)
+	}
+	return found, nil
+}
+
+func specName(spec string) string {
+	if spec == "" {
+		return "current"
+	}
+	return spec
+}
+
+// Remove удаляет задание из таблицы
+func (t *Table) Remove(j *Job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.remove(j)
+}
+
+func (t *Table) remove(j *Job) {
+	for i, other := range t.jobs {
+		if other == j {
+			t.jobs = append(t.jobs[:i], t.jobs[i+1:]...)
+			break
+		}
+	}
+	if t.current == j {
+		t.current = t.previous
+		t.previous = nil
+	}
+	if t.previous == j {
+		t.previous = nil
+	}
+	if t.current == nil && len(t.jobs) > 0 {
+		t.current = t.jobs[len(t.jobs)-1]
+	}
+}
+
+// Foreground передаёт терминал заданию и ждёт его завершения или остановки.
+// При cont заданию предварительно отправляется SIGCONT. Возвращает код
+// завершения последнего процесса, для остановленного задания — 128+SIGTSTP
+func (t *Table) Foreground(j *Job, cont bool) int {
+	if t.interactive {
+		_ = tcsetpgrp(t.tty, j.Pgid)
+	}
+	if cont {
+		t.markRunning(j)
+		_ = syscall.Kill(-j.Pgid, syscall.SIGCONT)
+	}
+
+	t.wait(j, true)
+
+	if t.interactive {
+		_ = tcsetpgrp(t.tty, t.shellPgid)
+	}
+
+	if j.State() == Stopped {
+		t.mu.Lock()
+		t.setCurrent(j)
+		// об остановке сообщает вызывающий код
+		j.notified = true
+		t.mu.Unlock()
+		return 128 + int(syscall.SIGTSTP)
+	}
+
+	t.Remove(j)
+	return j.Status()
+}
+
+// Background продолжает остановленное задание в фоне
+func (t *Table) Background(j *Job) error {
+	t.markRunning(j)
+	t.SetCurrent(j)
+	t.SetLastBackground(j)
+	return syscall.Kill(-j.Pgid, syscall.SIGCONT)
+}
+
+// Wait ждёт завершения задания без передачи терминала
+func (t *Table) Wait(j *Job) int {
+	t.wait(j, false)
+	if j.State() == Done {
+		t.Remove(j)
+	}
+	return j.Status()
+}
+
+func (t *Table) markRunning(j *Job) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, p := range j.Procs {
+		p.Stopped = false
+	}
+	j.notified = false
+}
+
+// wait блокируется, пока все процессы задания не завершатся.
+// При untraced ожидание прерывается остановкой любого процесса
+func (t *Table) wait(j *Job, untraced bool) {
+	options := 0
+	if untraced {
+		options = syscall.WUNTRACED
+	}
+	for _, p := range j.Procs {
+		// процессы может собрать Reap копии шелла, работающей в горутине
+		t.mu.Lock()
+		done := p.Done
+		t.mu.Unlock()
+		if done {
+			continue
+		}
+		for {
+			var ws syscall.WaitStatus
+			_, err := syscall.Wait4(p.Pid, &ws, options, nil)
+			if err == syscall.EINTR {
+				continue
+			}
+			t.mu.Lock()
+			if err != nil {
+				p.Done = true
+			} else {
+				t.update(p, ws)
+			}
+			t.mu.Unlock()
+			break
+		}
+		t.mu.Lock()
+		stopped := p.Stopped
+		if stopped {
+			// остальные процессы группы получили тот же сигнал
+			for _, other := range j.Procs {
+				if !other.Done {
+					other.Stopped = true
+				}
+			}
+		}
+		t.mu.Unlock()
+		if stopped {
+			return
+		}
+	}
+}
+
+func (t *Table) update(p *Process, ws syscall.WaitStatus) {
+	switch {
+	case ws.Stopped():
+		p.Stopped = true
+		p.Status = ws
+	case ws.Continued():
+		p.Stopped = false
+	default:
+		p.Done = true
+		p.Stopped = false
+		p.Status = ws
+	}
+}
+
+// Reap неблокирующе собирает статусы фоновых процессов
+func (t *Table) Reap() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, j := range t.jobs {
+		for _, p := range j.Procs {
+			if p.Done {
+				continue
+			}
+			var ws syscall.WaitStatus
+			pid, err := syscall.Wait4(p.Pid, &ws, syscall.WNOHANG|syscall.WUNTRACED|syscall.WCONTINUED, nil)
+			if err != nil {
+				if err == syscall.ECHILD {
+					p.Done = true
+				}
+				continue
+			}
+			if pid == p.Pid {
+				wasStopped := p.Stopped
+				t.update(p, ws)
+				if p.Stopped != wasStopped {
+					j.notified = false
+				}
+			}
+		}
+		if j.State() == Stopped && !j.notified {
+			t.setCurrent(j)
+		}
+	}
+}
+
+// Notifications возвращает строки о заданиях, изменивших состояние с прошлого вызова,
+// и удаляет из таблицы завершённые
+func (t *Table) Notifications() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var lines []string
+	var done []*Job
+	for _, j := range t.jobs {
+		state := j.State()
+		if state == Running || j.notified {
+			continue
+		}
+		j.notified = true
+		lines = append(lines, t.format(j))
+		if state == Done {
+			done = append(done, j)
+		}
+	}
+	for _, j := range done {
+		t.remove(j)
+	}
+	return lines
+}
+
+// Format форматирует строку задания в стиле bash
+func (t *Table) Format(j *Job) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.format(j)
+}
+
+func (t *Table) format(j *Job) string {
+	marker := byte(' ')
+	switch j {
+	case t.current:
+		marker = '+'
+	case t.previous:
+		marker = '-'
+	}
+	state := j.State().String()
+	if state == "Done" && j.Status() != 0 {
+		state = fmt.Sprintf("Exit %d", j.Status())
+		if text := j.Procs[len(j.Procs)-1].SignalText(); text != "" {
+			state = text
+		}
+	}
+	text := j.Text
+	if j.State() == Running {
+		text += " &"
+	}
+	return fmt.Sprintf("[%d]%c  %-24s%s", j.ID, marker, state, text)
+}
@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"runtime"
+	"syscall"
+	"unsafe"
+)
+
+// Значения how для rt_sigprocmask
+const (
+	sigBlock   = 0
+	sigSetmask = 2
+)
+
+// tcgetpgrp возвращает группу процессов, владеющую терминалом
+func tcgetpgrp(fd int) (int, error) {
+	var pgid int32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCGPGRP, uintptr(unsafe.Pointer(&pgid)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(pgid), nil
+}
+
+// tcsetpgrp передаёт терминал группе pgid. SIGTTOU блокируется на время вызова,
+// иначе шелл, находящийся в фоне, получит его сам при попытке забрать терминал
+func tcsetpgrp(fd, pgid int) error {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	set := uint64(1) << (uint(syscall.SIGTTOU) - 1)
+	var old uint64
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_RT_SIGPROCMASK, sigBlock,
+		uintptr(unsafe.Pointer(&set)), uintptr(unsafe.Pointer(&old)), 8, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	defer syscall.RawSyscall6(syscall.SYS_RT_SIGPROCMASK, sigSetmask,
+		uintptr(unsafe.Pointer(&old)), 0, 8, 0, 0)
+
+	p := int32(pgid)
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.TIOCSPGRP, uintptr(unsafe.Pointer(&p)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,618 @@
+// Package lineedit — редактор строки для интерактивного режима: перемещение курсора,
+// привязки клавиш в стиле Emacs, история с поиском по Ctrl+R и дополнение по Tab
+package lineedit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInterrupted возвращается, когда ввод строки прерван Ctrl+C
+var ErrInterrupted = errors.New("interrupted")
+
+// Candidate — вариант дополнения. Text заменяет дополняемое слово целиком,
+// Display показывается в списке вариантов
+type Candidate struct {
+	Text    string
+	Display string
+}
+
+// Completer получает текст строки до курсора и возвращает начало дополняемого
+// слова (смещение в байтах) и варианты его замены
+type Completer func(line string) (start int, candidates []Candidate)
+
+// Специальные клавиши, которые приходят escape-последовательностями
+const (
+	keyUp rune = -1 - iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyWordLeft
+	keyWordRight
+	keyKillWord
+	keyKillWordBack
+	keyUnknown
+)
+
+func ctrl(c byte) rune {
+	return rune(c & 0x1f)
+}
+
+// Editor читает строки с терминала в посимвольном режиме
+type Editor struct {
+	in       *os.File
+	out      *os.File
+	history  *History
+	complete Completer
+
+	// состояние редактируемой строки
+	prompt string
+	buf    []rune
+	pos    int
+	// offset — первый видимый символ, когда строка не помещается в терминал
+	offset int
+	// histIndex — текущая строка истории; len(entries) — новая строка, сохранённая в draft
+	histIndex int
+	draft     []rune
+	killed    []rune
+	lastTab   bool
+}
+
+// New создаёт редактор, читающий с in и рисующий строку в out
+func New(in, out *os.File, history *History, complete Completer) *Editor {
+	if history == nil {
+		history = NewHistory("", 0)
+	}
+	return &Editor{in: in, out: out, history: history, complete: complete}
+}
+
+// ReadLine выводит приглашение и читает строку без завершающего перевода строки.
+// Ctrl+D в пустой строке возвращает io.EOF, Ctrl+C — ErrInterrupted.
+// Многострочное приглашение печатается целиком, редактируется только его последняя строка.
+// Если терминал не удаётся перевести в посимвольный режим, строка читается как есть
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if i := strings.LastIndexByte(prompt, '\n'); i >= 0 {
+		e.write(stripMarkers(prompt[:i+1]))
+		prompt = prompt[i+1:]
+	}
+	old, err := makeRaw(int(e.in.Fd()))
+	if err != nil {
+		e.write(stripMarkers(prompt))
+		return e.readCooked()
+	}
+	defer setTermios(int(e.in.Fd()), old)
+
+	e.prompt = prompt
+	e.buf, e.pos, e.offset = nil, 0, 0
+	e.histIndex, e.draft = len(e.history.Entries()), nil
+	e.lastTab = false
+	e.refresh()
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			return "", err
+		}
+		if key == ctrl('R') {
+			next, ok, err := e.search()
+			if err != nil {
+				return "", err
+			}
+			if !ok {
+				e.refresh()
+				continue
+			}
+			key = next
+		}
+		line, done, err := e.handle(key)
+		if done {
+			return line, err
+		}
+		e.refresh()
+	}
+}
+
+// handle обрабатывает клавишу; done означает, что ввод строки закончен
+func (e *Editor) handle(key rune) (line string, done bool, err error) {
+	tab := false
+	defer func() { e.lastTab = tab }()
+
+	switch key {
+	case '\r', '\n':
+		e.pos = len(e.buf)
+		e.refresh()
+		e.write("\n")
+		return string(e.buf), true, nil
+	case ctrl('C'):
+		e.write("^C\n")
+		return "", true, ErrInterrupted
+	case ctrl('D'):
+		if len(e.buf) == 0 {
+			return "", true, io.EOF
+		}
+		e.deleteAt(e.pos)
+	case ctrl('A'), keyHome:
+		e.pos = 0
+	case ctrl('E'), keyEnd:
+		e.pos = len(e.buf)
+	case ctrl('B'), keyLeft:
+		if e.pos > 0 {
+			e.pos--
+		}
+	case ctrl('F'), keyRight:
+		if e.pos < len(e.buf) {
+			e.pos++
+		}
+	case keyWordLeft:
+		e.pos = e.wordStart()
+	case keyWordRight:
+		e.pos = e.wordEnd()
+	case 0x7f, ctrl('H'):
+		if e.pos > 0 {
+			e.pos--
+			e.deleteAt(e.pos)
+		}
+	case keyDelete:
+		e.deleteAt(e.pos)
+	case ctrl('K'):
+		e.kill(e.pos, len(e.buf))
+	case ctrl('U'):
+		e.kill(0, e.pos)
+	case ctrl('W'):
+		// Ctrl+W удаляет до предыдущего пробела, а не до границы слова
+		start := e.pos
+		for start > 0 && unicode.IsSpace(e.buf[start-1]) {
+			start--
+		}
+		for start > 0 && !unicode.IsSpace(e.buf[start-1]) {
+			start--
+		}
+		e.kill(start, e.pos)
+	case keyKillWord:
+		e.kill(e.pos, e.wordEnd())
+	case keyKillWordBack:
+		e.kill(e.wordStart(), e.pos)
+	case ctrl('Y'):
+		e.insert(e.killed...)
+	case ctrl('T'):
+		if e.pos > 0 && len(e.buf) > 1 {
+			if e.pos == len(e.buf) {
+				e.pos--
+			}
+			e.buf[e.pos-1], e.buf[e.pos] = e.buf[e.pos], e.buf[e.pos-1]
+			e.pos++
+		}
+	case ctrl('L'):
+		e.write("\x1b[H\x1b[2J")
+	case ctrl('P'), keyUp:
+		e.historyMove(-1)
+	case ctrl('N'), keyDown:
+		e.historyMove(1)
+	case '\t':
+		tab = true
+		e.completeWord()
+	default:
+		if key >= ' ' && key != 0x7f {
+			e.insert(key)
+		}
+	}
+	return "", false, nil
+}
+
+func (e *Editor) insert(runes ...rune) {
+	buf := make([]rune, 0, len(e.buf)+len(runes))
+	buf = append(buf, e.buf[:e.pos]...)
+	buf = append(buf, runes...)
+	e.buf = append(buf, e.buf[e.pos:]...)
+	e.pos += len(runes)
+}
+
+func (e *Editor) deleteAt(i int) {
+	if i < len(e.buf) {
+		e.buf = append(e.buf[:i], e.buf[i+1:]...)
+	}
+}
+
+// kill вырезает символы [from, to) в буфер для Ctrl+Y
+func (e *Editor) kill(from, to int) {
+	if from >= to {
+		return
+	}
+	e.killed = append([]rune(nil), e.buf[from:to]...)
+	e.buf = append(e.buf[:from], e.buf[to:]...)
+	e.pos = from
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// wordStart возвращает начало слова слева от курсора
+func (e *Editor) wordStart() int {
+	i := e.pos
+	for i > 0 && !isWordRune(e.buf[i-1]) {
+		i--
+	}
+	for i > 0 && isWordRune(e.buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd возвращает конец слова справа от курсора
+func (e *Editor) wordEnd() int {
+	i := e.pos
+	for i < len(e.buf) && !isWordRune(e.buf[i]) {
+		i++
+	}
+	for i < len(e.buf) && isWordRune(e.buf[i]) {
+		i++
+	}
+	return i
+}
+
+// historyMove листает историю; редактируемая новая строка сохраняется
+func (e *Editor) historyMove(delta int) {
+	entries := e.history.Entries()
+	i := e.histIndex + delta
+	if i < 0 || i > len(entries) {
+		return
+	}
+	if e.histIndex == len(entries) {
+		e.draft = append([]rune(nil), e.buf...)
+	}
+	e.histIndex = i
+	if i == len(entries) {
+		e.buf = e.draft
+	} else {
+		e.buf = []rune(entries[i])
+	}
+	e.pos = len(e.buf)
+}
+
+// search выполняет инкрементальный поиск по истории назад. Возвращает клавишу,
+// завершившую поиск, чтобы её обработал редактор; ok == false при отмене
+func (e *Editor) search() (key rune, ok bool, err error) {
+	prompt := e.prompt
+	defer func() { e.prompt = prompt }()
+	origBuf, origPos := append([]rune(nil), e.buf...), e.pos
+
+	entries := e.history.Entries()
+	var query []rune
+	match := len(entries)
+	failed := false
+	find := func(from int) bool {
+		for i := min(from, len(entries)-1); i >= 0; i-- {
+			if j := strings.Index(entries[i], string(query)); j >= 0 {
+				match = i
+				e.buf = []rune(entries[i])
+				e.pos = utf8.RuneCountInString(entries[i][:j])
+				return true
+			}
+		}
+		return false
+	}
+
+	for {
+		label := "(reverse-i-search)"
+		if failed {
+			label = "(failed reverse-i-search)"
+		}
+		e.prompt = fmt.Sprintf("%s`%s': ", label, string(query))
+		e.refresh()
+
+		key, err := e.readKey()
+		if err != nil {
+			return 0, false, err
+		}
+		switch {
+		case key == ctrl('R'):
+			if len(query) > 0 {
+				failed = !find(match - 1)
+			}
+		case key == 0x7f || key == ctrl('H'):
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				failed = !find(len(entries) - 1)
+			}
+		case key == ctrl('G') || key == ctrl('C'):
+			e.buf, e.pos = origBuf, origPos
+			return 0, false, nil
+		case key >= ' ':
+			query = append(query, key)
+			failed = !find(match)
+		default:
+			if match < len(entries) {
+				e.histIndex = match
+			}
+			e.prompt = prompt
+			return key, true, nil
+		}
+	}
+}
+
+// completeWord дополняет слово перед курсором. Единственный вариант подставляется
+// целиком, при нескольких — их общее начало, а повторный Tab выводит список
+func (e *Editor) completeWord() {
+	if e.complete == nil {
+		return
+	}
+	prefix := string(e.buf[:e.pos])
+	start, candidates := e.complete(prefix)
+	if len(candidates) == 0 {
+		e.write("\a")
+		return
+	}
+
+	var insert string
+	if len(candidates) == 1 {
+		insert = candidates[0].Text
+		if !strings.HasSuffix(insert, "/") {
+			insert += " "
+		}
+	} else {
+		insert = commonPrefix(candidates)
+		if len(insert) <= len(prefix)-start {
+			if e.lastTab {
+				e.list(candidates)
+			} else {
+				e.write("\a")
+			}
+			return
+		}
+	}
+
+	from := utf8.RuneCountInString(prefix[:start])
+	rest := e.buf[e.pos:]
+	e.buf = append(append([]rune(nil), e.buf[:from]...), []rune(insert)...)
+	e.pos = len(e.buf)
+	e.buf = append(e.buf, rest...)
+}
+
+func commonPrefix(candidates []Candidate) string {
+	prefix := candidates[0].Text
+	for _, c := range candidates[1:] {
+		n := 0
+		for n < len(prefix) && n < len(c.Text) && prefix[n] == c.Text[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	// общее начало не должно обрываться посреди символа UTF-8
+	for len(prefix) > 0 && !utf8.ValidString(prefix) {
+		prefix = prefix[:len(prefix)-1]
+	}
+	return prefix
+}
+
+// list выводит варианты дополнения столбцами под строкой ввода
+func (e *Editor) list(candidates []Candidate) {
+	names := make([]string, len(candidates))
+	colWidth := 0
+	for i, c := range candidates {
+		names[i] = c.Display
+		colWidth = max(colWidth, utf8.RuneCountInString(c.Display)+2)
+	}
+	sort.Strings(names)
+	cols := max(1, width(int(e.out.Fd()))/colWidth)
+	rows := (len(names) + cols - 1) / cols
+
+	var b strings.Builder
+	b.WriteString("\n")
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			i := c*rows + r
+			if i >= len(names) {
+				break
+			}
+			if c < cols-1 && i+rows < len(names) {
+				fmt.Fprintf(&b, "%-*s", colWidth, names[i])
+			} else {
+				b.WriteString(names[i])
+			}
+		}
+		b.WriteString("\n")
+	}
+	e.write(b.String())
+}
+
+// refresh перерисовывает строку ввода. Длинная строка прокручивается
+// по горизонтали так, чтобы курсор оставался видимым
+func (e *Editor) refresh() {
+	promptWidth := visibleWidth(e.prompt)
+	avail := max(1, width(int(e.out.Fd()))-promptWidth-1)
+	if e.pos < e.offset {
+		e.offset = e.pos
+	}
+	if e.pos-e.offset > avail {
+		e.offset = e.pos - avail
+	}
+	end := min(len(e.buf), e.offset+avail)
+
+	var b strings.Builder
+	b.WriteString("\r")
+	b.WriteString(stripMarkers(e.prompt))
+	// перевод строки многострочной команды из истории показывается одним знаком
+	b.WriteString(strings.ReplaceAll(string(e.buf[e.offset:end]), "\n", "↵"))
+	b.WriteString("\x1b[K\r")
+	if col := promptWidth + e.pos - e.offset; col > 0 {
+		fmt.Fprintf(&b, "\x1b[%dC", col)
+	}
+	e.write(b.String())
+}
+
+func (e *Editor) write(s string) {
+	_, _ = e.out.WriteString(s)
+}
+
+// readRune читает один символ UTF-8. Чтение идёт по байту, чтобы не забрать
+// из терминала ввод, предназначенный для следующей команды
+func (e *Editor) readRune() (rune, error) {
+	var b [utf8.UTFMax]byte
+	if _, err := io.ReadFull(e.in, b[:1]); err != nil {
+		return 0, err
+	}
+	n := 1
+	switch {
+	case b[0] >= 0xf0:
+		n = 4
+	case b[0] >= 0xe0:
+		n = 3
+	case b[0] >= 0xc0:
+		n = 2
+	}
+	if n > 1 {
+		if _, err := io.ReadFull(e.in, b[1:n]); err != nil {
+			return 0, err
+		}
+	}
+	r, _ := utf8.DecodeRune(b[:n])
+	return r, nil
+}
+
+// readKey читает клавишу, разбирая escape-последовательности стрелок,
+// Home, End, Delete и сочетаний с Alt
+func (e *Editor) readKey() (rune, error) {
+	r, err := e.readRune()
+	if err != nil || r != 0x1b {
+		return r, err
+	}
+	r, err = e.readRune()
+	if err != nil {
+		return 0, err
+	}
+	switch r {
+	case '[', 'O':
+		var params []rune
+		final := rune(0)
+		for {
+			c, err := e.readRune()
+			if err != nil {
+				return 0, err
+			}
+			if ('0' <= c && c <= '9') || c == ';' {
+				params = append(params, c)
+				continue
+			}
+			final = c
+			break
+		}
+		// Ctrl+стрелки передают модификатор 5: ESC [1;5D
+		modified := strings.HasSuffix(string(params), ";5")
+		switch final {
+		case 'A':
+			return keyUp, nil
+		case 'B':
+			return keyDown, nil
+		case 'C':
+			if modified {
+				return keyWordRight, nil
+			}
+			return keyRight, nil
+		case 'D':
+			if modified {
+				return keyWordLeft, nil
+			}
+			return keyLeft, nil
+		case 'H':
+			return keyHome, nil
+		case 'F':
+			return keyEnd, nil
+		case '~':
+			switch string(params) {
+			case "1", "7":
+				return keyHome, nil
+			case "4", "8":
+				return keyEnd, nil
+			case "3":
+				return keyDelete, nil
+			}
+		}
+	case 'b':
+		return keyWordLeft, nil
+	case 'f':
+		return keyWordRight, nil
+	case 'd':
+		return keyKillWord, nil
+	case 0x7f, ctrl('H'):
+		return keyKillWordBack, nil
+	}
+	return keyUnknown, nil
+}
+
+// readCooked читает строку в обычном режиме терминала
+func (e *Editor) readCooked() (string, error) {
+	var line []byte
+	var b [1]byte
+	for {
+		if _, err := e.in.Read(b[:]); err != nil {
+			if len(line) > 0 && errors.Is(err, io.EOF) {
+				return string(line), nil
+			}
+			return "", err
+		}
+		if b[0] == '\n' {
+			return string(line), nil
+		}
+		line = append(line, b[0])
+	}
+}
+
+// visibleWidth возвращает ширину приглашения на экране без escape-последовательностей
+// и участков между \001 и \002, которыми помечаются непечатаемые символы
+func visibleWidth(s string) int {
+	n := 0
+	hidden := false
+	for i := 0; i < len(s); {
+		r, w := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == '\001':
+			hidden = true
+		case r == '\002':
+			hidden = false
+		case r == 0x1b:
+			i += escapeLen(s[i:])
+			continue
+		case !hidden && r >= ' ':
+			n++
+		}
+		i += w
+	}
+	return n
+}
+
+// escapeLen возвращает длину escape-последовательности в начале s:
+// CSI (ESC [ ... буква) или OSC (ESC ] ... BEL)
+func escapeLen(s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']':
+		if i := strings.IndexByte(s, '\a'); i >= 0 {
+			return i + 1
+		}
+		return len(s)
+	}
+	return 2
+}
+
+// stripMarkers удаляет маркеры \001 и \002 перед выводом приглашения
+func stripMarkers(s string) string {
+	return strings.NewReplacer("\001", "", "\002", "").Replace(s)
+}
@@ -0,0 +1,116 @@
+package lineedit
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// History — список введённых команд. Если задан файл, история загружается из него
+// и дописывается в него после каждой команды. Многострочная команда хранится
+// одной записью, а в файле её строки, кроме последней, заканчиваются на \
+type History struct {
+	entries []string
+	path    string
+	max     int
+}
+
+// NewHistory создаёт историю не длиннее max строк, связанную с файлом path.
+// Пустой path отключает сохранение
+func NewHistory(path string, max int) *History {
+	h := &History{path: path, max: max}
+	if path == "" {
+		return h
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	var entry string
+	for sc.Scan() {
+		line := sc.Text()
+		if continued(line) {
+			entry += line[:len(line)-1] + "\n"
+			continue
+		}
+		if entry += line; entry != "" {
+			h.entries = append(h.entries, entry)
+		}
+		entry = ""
+	}
+	h.trim()
+	return h
+}
+
+// Add добавляет команду в историю. Пустые команды и повтор последней не сохраняются
+func (h *History) Add(line string) {
+	line = strings.TrimRight(line, "\n")
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	if n := len(h.entries); n > 0 && h.entries[n-1] == line {
+		return
+	}
+	h.entries = append(h.entries, line)
+	if h.trim() {
+		h.save()
+		return
+	}
+	if h.path == "" {
+		return
+	}
+	f, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.WriteString(encode(line))
+}
+
+// Entries возвращает строки истории от старых к новым
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Clear очищает историю вместе с файлом
+func (h *History) Clear() {
+	h.entries = nil
+	h.save()
+}
+
+// trim отбрасывает старые строки сверх max и сообщает, были ли они
+func (h *History) trim() bool {
+	if h.max <= 0 || len(h.entries) <= h.max {
+		return false
+	}
+	h.entries = append([]string(nil), h.entries[len(h.entries)-h.max:]...)
+	return true
+}
+
+// save перезаписывает файл истории целиком
+func (h *History) save() {
+	if h.path == "" {
+		return
+	}
+	var b strings.Builder
+	for _, line := range h.entries {
+		b.WriteString(encode(line))
+	}
+	_ = os.WriteFile(h.path, []byte(b.String()), 0o600)
+}
+
+// encode записывает команду для файла истории: внутренние переводы строк
+// предваряются \, чтобы при загрузке строки снова собрались в одну запись
+func encode(line string) string {
+	return strings.ReplaceAll(line, "\n", "\\\n") + "\n"
+}
+
+// continued сообщает, что строка файла истории продолжается следующей:
+// она заканчивается нечётным числом \. Строки завершённых команд так
+// не заканчиваются — одиночная \ в конце продолжила бы саму команду
+func continued(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, "\\"))
+	return n%2 == 1
+}
@@ -0,0 +1,39 @@
+package lineedit
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistoryMultiline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	h := NewHistory(path, 10)
+	entries := []string{
+		"echo one",
+		"echo \"a\nb\"",
+		`echo \\`,
+		"for i in 1 2\ndo\n  echo $i\ndone",
+		`printf '%s\n' x`,
+	}
+	for _, e := range entries {
+		h.Add(e + "\n")
+	}
+	h.Add("echo one\n")
+	h.Add("  \n")
+	want := append(entries, "echo one")
+	if got := h.Entries(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Entries() = %q, want %q", got, want)
+	}
+	if got := NewHistory(path, 10).Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("NewHistory(saved).Entries() = %q, want %q", got, want)
+	}
+
+	// обрезка переписывает файл целиком в том же формате
+	h = NewHistory(path, 3)
+	h.Add("echo last\n")
+	want = []string{entries[4], "echo one", "echo last"}
+	if got := NewHistory(path, 10).Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("after trim Entries() = %q, want %q", got, want)
+	}
+}
@@ -1,9 +1,37 @@
 package model
 
-// Command описывает одну стадию пайплайна
+// Command описывает одну стадию пайплайна.
+// Env — дополнительные переменные окружения NAME=value только для этой команды,
+// Redirects — перенаправления, применяемые по порядку.
+// Непустой Script — подоболочка, которая выполняет скрипт в копии шелла
+// внутри процесса; Args тогда содержит только $0 для сообщений
 type Command struct {
 	Args      []string
-	InFile    string
-	OutFile   string
-	AppendOut bool
+	Env       []string
+	Redirects []Redirect
+	Script    string
+}
+
+// RedirectKind — вид перенаправления
+type RedirectKind int
+
+const (
+	RedirIn        RedirectKind = iota // n<file
+	RedirOut                           // n>file, с noclobber не перезаписывает файл
+	RedirClobber                       // n>|file
+	RedirAppend                        // n>>file
+	RedirReadWrite                     // n<>file
+	RedirDup                           // n>&m, n<&m
+	RedirClose                         // n>&-, n<&-
+	RedirData                          // here-document и here-string: Data подаётся на ввод
+)
+
+// Redirect — перенаправление дескриптора Fd в файл Target, копию дескриптора
+// DupFd или текст Data
+type Redirect struct {
+	Fd     int
+	Kind   RedirectKind
+	Target string
+	DupFd  int
+	Data   string
 }
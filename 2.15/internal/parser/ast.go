@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// List — последовательность and-or списков, разделённых ; & или переводом строки
+type List struct {
+	Items []*ListItem
+}
+
+// ListItem — элемент списка; Background выставляется для команд, завершённых &
+type ListItem struct {
+	AndOr      *AndOr
+	Background bool
+}
+
+// AndOr — пайплайны, соединённые && и ||. Ops[i] связывает Pipelines[i] и Pipelines[i+1].
+// Text — исходный текст списка, по нему шелл перезапускает себя для фонового выполнения
+type AndOr struct {
+	Pipelines []*Pipeline
+	Ops       []TokenKind
+	Text      string
+}
+
+// Pipeline — стадии, соединённые |. Negate выставляется для ! перед пайплайном
+type Pipeline struct {
+	Commands []Command
+	Negate   bool
+}
+
+// Command — стадия пайплайна: простая команда или составная конструкция
+type Command interface {
+	String() string
+}
+
+// SimpleCommand — команда с присваиваниями-префиксами, аргументами и перенаправлениями.
+// Команда без слов только присваивает переменные шелла
+type SimpleCommand struct {
+	Assigns   []Assign
+	Words     []Word
+	Redirects []Redirect
+}
+
+// Assign — присваивание NAME=value
+type Assign struct {
+	Name  string
+	Value Word
+}
+
+// Compound — общие поля составных команд: исходный текст и перенаправления
+// после закрывающего слова (например, done < file)
+type Compound struct {
+	Text      string
+	Redirects []Redirect
+}
+
+// String возвращает исходный текст конструкции
+func (c *Compound) String() string {
+	return c.Text
+}
+
+// IfClause — if/elif/else. Conds[i] проверяется перед выполнением Bodies[i]
+type IfClause struct {
+	Compound
+	Conds  []*List
+	Bodies []*List
+	Else   *List
+}
+
+// WhileClause — цикл while, или until при Until
+type WhileClause struct {
+	Compound
+	Cond  *List
+	Body  *List
+	Until bool
+}
+
+// ForClause — цикл for. Без in перебираются позиционные параметры
+type ForClause struct {
+	Compound
+	Var   string
+	Words []Word
+	HasIn bool
+	Body  *List
+}
+
+// CaseClause — case WORD in ... esac
+type CaseClause struct {
+	Compound
+	Word  Word
+	Items []*CaseItem
+}
+
+// CaseItem — ветка case: шаблоны через | и тело
+type CaseItem struct {
+	Patterns []Word
+	Body     *List
+}
+
+// Group — { list; }, выполняется в текущем шелле
+type Group struct {
+	Compound
+	Body *List
+}
+
+// Subshell — ( list ), выполняется в отдельном процессе шелла.
+// Script — текст списка внутри скобок, который получает дочерний шелл
+type Subshell struct {
+	Compound
+	Body   *List
+	Script string
+}
+
+// FuncDef — определение функции name() { ... }. Text — исходный текст
+// определения целиком, по нему функция передаётся дочернему шеллу
+type FuncDef struct {
+	Compound
+	Name string
+	Body Command
+}
+
+// Redirect — перенаправление [Fd]Op Target. Fd равен -1, если номер дескриптора
+// не указан и используется номер по умолчанию для оператора.
+// Для here-document Target — ограничитель, Body — текст документа, который
+// раскрывается, только если ограничитель записан без кавычек
+type Redirect struct {
+	Fd     int
+	Op     TokenKind
+	Target Word
+	Body   string
+}
+
+// DefaultFd возвращает дескриптор, к которому относится перенаправление:
+// явно указанный или 0 для операторов ввода и 1 для вывода
+func (r Redirect) DefaultFd() int {
+	if r.Fd >= 0 {
+		return r.Fd
+	}
+	switch r.Op {
+	case TokLess, TokLessAnd, TokLessGreat, TokDLess, TokDLessDash, TokTLess:
+		return 0
+	}
+	return 1
+}
+
+// String восстанавливает текст перенаправления
+func (r Redirect) String() string {
+	text := r.Op.String() + r.Target.Raw
+	if strings.HasPrefix(r.Target.Raw, "<(") || strings.HasPrefix(r.Target.Raw, ">(") {
+		// без пробела > >(cmd) читался бы как >>(cmd)
+		text = r.Op.String() + " " + r.Target.Raw
+	}
+	if r.Fd >= 0 {
+		text = strconv.Itoa(r.Fd) + text
+	}
+	return text
+}
+
+// Word — слово в исходном виде, с кавычками; раскрывается непосредственно перед выполнением
+type Word struct {
+	Raw string
+}
+
+// String восстанавливает текст пайплайна для вывода в списке заданий
+func (p *Pipeline) String() string {
+	stages := make([]string, len(p.Commands))
+	for i, c := range p.Commands {
+		stages[i] = c.String()
+	}
+	text := strings.Join(stages, " | ")
+	if p.Negate {
+		text = "! " + text
+	}
+	return text
+}
+
+// String восстанавливает текст простой команды
+func (c *SimpleCommand) String() string {
+	var parts []string
+	for _, a := range c.Assigns {
+		parts = append(parts, a.Name+"="+a.Value.Raw)
+	}
+	for _, w := range c.Words {
+		parts = append(parts, w.Raw)
+	}
+	for _, r := range c.Redirects {
+		parts = append(parts, r.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// Quote заключает слово в одинарные кавычки, если в нём есть спецсимволы
+func Quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool { return strings.ContainsRune(" \t\n|&;<>()$`\\\"'*?[#~", r) }) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,461 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrIncomplete означает, что ввод оборвался посреди конструкции
+// (незакрытая кавычка, висящий оператор) и его можно дочитать следующей строкой
+var ErrIncomplete = errors.New("unexpected EOF")
+
+// TokenKind определяет тип лексемы
+type TokenKind int
+
+const (
+	TokEOF       TokenKind = iota
+	TokWord                // слово в исходном виде, с кавычками
+	TokPipe                // |
+	TokOrIf                // ||
+	TokAndIf               // &&
+	TokSemi                // ;
+	TokAmp                 // &
+	TokLess                // <
+	TokGreat               // >
+	TokDGreat              // >>
+	TokNewline             // перевод строки
+	TokLParen              // (
+	TokRParen              // )
+	TokDSemi               // ;;
+	TokDLess               // <<
+	TokDLessDash           // <<-
+	TokTLess               // <<<
+	TokLessAnd             // <&
+	TokGreatAnd            // >&
+	TokLessGreat           // <>
+	TokClobber             // >|
+	TokAndGreat            // &>
+	TokAndDGreat           // &>>
+	TokIONumber            // номер дескриптора перед перенаправлением: 2 в 2>file
+)
+
+// Token — лексема входной строки. Pos и End — границы лексемы во входной строке
+type Token struct {
+	Kind  TokenKind
+	Value string
+	Pos   int
+	End   int
+	// Quoted выставляется, если в слове были кавычки или экранирование;
+	// такие слова не считаются зарезервированными (if, do, ...)
+	Quoted bool
+	// Body — текст here-document для << и <<-; BodyStart и BodyEnd — его границы
+	// во входной строке вместе со строкой-ограничителем
+	Body      string
+	BodyStart int
+	BodyEnd   int
+}
+
+func (k TokenKind) String() string {
+	switch k {
+	case TokEOF:
+		return "end of input"
+	case TokWord:
+		return "word"
+	case TokPipe:
+		return "|"
+	case TokOrIf:
+		return "||"
+	case TokAndIf:
+		return "&&"
+	case TokSemi:
+		return ";"
+	case TokAmp:
+		return "&"
+	case TokLess:
+		return "<"
+	case TokGreat:
+		return ">"
+	case TokDGreat:
+		return ">>"
+	case TokNewline:
+		return "newline"
+	case TokLParen:
+		return "("
+	case TokRParen:
+		return ")"
+	case TokDSemi:
+		return ";;"
+	case TokIONumber:
+		return "number"
+	default:
+		for _, op := range operators {
+			if op.kind == k {
+				return op.text
+			}
+		}
+		return "unknown"
+	}
+}
+
+type operator struct {
+	text string
+	kind TokenKind
+}
+
+// operators упорядочены так, чтобы длинные операторы проверялись раньше коротких
+var operators = []operator{
+	{"<<<", TokTLess},
+	{"<<-", TokDLessDash},
+	{"&>>", TokAndDGreat},
+	{"||", TokOrIf},
+	{"&&", TokAndIf},
+	{">>", TokDGreat},
+	{";;", TokDSemi},
+	{"<<", TokDLess},
+	{"<&", TokLessAnd},
+	{">&", TokGreatAnd},
+	{"<>", TokLessGreat},
+	{">|", TokClobber},
+	{"&>", TokAndGreat},
+	{"|", TokPipe},
+	{";", TokSemi},
+	{"&", TokAmp},
+	{"<", TokLess},
+	{">", TokGreat},
+	{"(", TokLParen},
+	{")", TokRParen},
+}
+
+// Lex разбивает строку на лексемы с учётом кавычек и экранирования.
+// Комментарии от # до конца строки пропускаются, \ перед переводом строки склеивает строки.
+// Тела here-document читаются со строк, следующих за переводом строки,
+// и сохраняются в лексеме оператора << или <<-
+func Lex(input string) ([]Token, error) {
+	var (
+		tokens []Token
+		// heredocs — индексы операторов <<, чьи тела ещё не прочитаны
+		heredocs []int
+	)
+	i := 0
+	for {
+		for i < len(input) {
+			if input[i] == ' ' || input[i] == '\t' {
+				i++
+			} else if strings.HasPrefix(input[i:], "\\\n") {
+				if i+2 == len(input) {
+					return nil, fmt.Errorf("%w after `\\'", ErrIncomplete)
+				}
+				i += 2
+			} else {
+				break
+			}
+		}
+		if i >= len(input) {
+			if len(heredocs) > 0 {
+				return nil, fmt.Errorf("%w while looking for here-document delimiter", ErrIncomplete)
+			}
+			tokens = append(tokens, Token{Kind: TokEOF, Pos: i, End: i})
+			return tokens, nil
+		}
+
+		switch input[i] {
+		case '#':
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+			continue
+		case '\n':
+			tokens = append(tokens, Token{Kind: TokNewline, Value: "\n", Pos: i, End: i + 1})
+			i++
+			for _, k := range heredocs {
+				next, err := readHeredoc(input, i, tokens, k)
+				if err != nil {
+					return nil, err
+				}
+				i = next
+			}
+			heredocs = nil
+			continue
+		}
+
+		if op, ok := matchOperator(input[i:]); ok && !isProcSubst(input, i) {
+			if op.kind == TokDLess || op.kind == TokDLessDash {
+				heredocs = append(heredocs, len(tokens))
+			}
+			tokens = append(tokens, Token{Kind: op.kind, Value: op.text, Pos: i, End: i + len(op.text)})
+			i += len(op.text)
+			continue
+		}
+
+		start := i
+		if isProcSubst(input, i) {
+			// подстановка процесса начинает слово, за ней могут идти другие символы
+			end, err := skipNested(input, i+1, ')')
+			if err != nil {
+				return nil, err
+			}
+			i = end
+		}
+		_, next, err := lexWord(input, i)
+		if err != nil {
+			return nil, err
+		}
+		word := input[start:next]
+		quoted := strings.ContainsAny(input[start:next], `'"\`)
+		kind := TokWord
+		if isDigits(word) && next < len(input) && (input[next] == '<' || input[next] == '>') {
+			kind = TokIONumber
+		}
+		tokens = append(tokens, Token{Kind: kind, Value: word, Pos: start, End: next, Quoted: quoted})
+		i = next
+	}
+}
+
+// readHeredoc читает тело here-document для оператора tokens[k], начиная с позиции i,
+// до строки-ограничителя и возвращает позицию после неё. Для <<- из строк тела
+// и ограничителя удаляются ведущие табуляции
+func readHeredoc(input string, i int, tokens []Token, k int) (int, error) {
+	if k+1 >= len(tokens) || tokens[k+1].Kind != TokWord {
+		// ошибку «нет ограничителя» сообщит парсер
+		return i, nil
+	}
+	delim := Unquote(tokens[k+1].Value)
+	stripTabs := tokens[k].Kind == TokDLessDash
+
+	var body strings.Builder
+	start := i
+	for i < len(input) {
+		end := strings.IndexByte(input[i:], '\n')
+		next := i + end + 1
+		if end < 0 {
+			next = len(input)
+			end = len(input) - i
+		}
+		line := input[i : i+end]
+		if stripTabs {
+			line = strings.TrimLeft(line, "\t")
+		}
+		if line == delim {
+			tokens[k].Body = body.String()
+			tokens[k].BodyStart, tokens[k].BodyEnd = start, next
+			return next, nil
+		}
+		body.WriteString(line)
+		body.WriteByte('\n')
+		i = next
+	}
+	return 0, fmt.Errorf("%w while looking for here-document delimiter `%s'", ErrIncomplete, delim)
+}
+
+// Unquote снимает кавычки и экранирование со слова без подстановок,
+// например с ограничителя here-document
+func Unquote(word string) string {
+	var b strings.Builder
+	for i := 0; i < len(word); i++ {
+		switch c := word[i]; c {
+		case '\'':
+			end := strings.IndexByte(word[i+1:], '\'')
+			if end < 0 {
+				end = len(word) - i - 1
+			}
+			b.WriteString(word[i+1 : i+1+end])
+			i += end + 1
+		case '"':
+			for i++; i < len(word) && word[i] != '"'; i++ {
+				if word[i] == '\\' && i+1 < len(word) && strings.IndexByte("$`\"\\", word[i+1]) >= 0 {
+					i++
+				}
+				b.WriteByte(word[i])
+			}
+		case '\\':
+			if i+1 < len(word) {
+				i++
+				b.WriteByte(word[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func matchOperator(s string) (operator, bool) {
+	for _, op := range operators {
+		if strings.HasPrefix(s, op.text) {
+			return op, true
+		}
+	}
+	return operator{}, false
+}
+
+// isProcSubst сообщает, что в позиции i начинается подстановка процесса <(...) или >(...)
+func isProcSubst(input string, i int) bool {
+	return (input[i] == '<' || input[i] == '>') && i+1 < len(input) && input[i+1] == '('
+}
+
+func isWordBreak(c byte) bool {
+	switch c {
+	case ' ', '\t', '\n', '|', '&', ';', '<', '>', '(', ')':
+		return true
+	}
+	return false
+}
+
+// lexWord читает одно слово в исходном виде: кавычки и экранирование
+// остаются на месте и снимаются при раскрытии. Подстановка ${...} входит в слово целиком
+func lexWord(input string, i int) (string, int, error) {
+	start := i
+	for i < len(input) && !isWordBreak(input[i]) {
+		switch input[i] {
+		case '\\':
+			if i+1 >= len(input) || (input[i+1] == '\n' && i+2 == len(input)) {
+				// строка продолжается на следующей
+				return "", 0, fmt.Errorf("%w after `\\'", ErrIncomplete)
+			}
+			i += 2
+		case '\'':
+			end := strings.IndexByte(input[i+1:], '\'')
+			if end < 0 {
+				return "", 0, fmt.Errorf("%w while looking for matching `''", ErrIncomplete)
+			}
+			i += end + 2
+		case '"':
+			next, err := skipDoubleQuoted(input, i)
+			if err != nil {
+				return "", 0, err
+			}
+			i = next
+		case '$':
+			next, err := skipDollar(input, i)
+			if err != nil {
+				return "", 0, err
+			}
+			i = next
+		case '`':
+			next, err := skipBackquoted(input, i)
+			if err != nil {
+				return "", 0, err
+			}
+			i = next
+		default:
+			i++
+		}
+	}
+	return input[start:i], i, nil
+}
+
+// skipDoubleQuoted пропускает строку в двойных кавычках, начиная с открывающей
+func skipDoubleQuoted(input string, i int) (int, error) {
+	for i++; i < len(input); {
+		switch input[i] {
+		case '"':
+			return i + 1, nil
+		case '\\':
+			i += 2
+		case '$':
+			next, err := skipDollar(input, i)
+			if err != nil {
+				return 0, err
+			}
+			i = next
+		case '`':
+			next, err := skipBackquoted(input, i)
+			if err != nil {
+				return 0, err
+			}
+			i = next
+		default:
+			i++
+		}
+	}
+	return 0, fmt.Errorf("%w while looking for matching `\"'", ErrIncomplete)
+}
+
+// skipDollar пропускает $ и следующую за ним подстановку ${...}, $(...) или $((...))
+func skipDollar(input string, i int) (int, error) {
+	if i+1 >= len(input) {
+		return i + 1, nil
+	}
+	switch input[i+1] {
+	case '{':
+		return skipNested(input, i+1, '}')
+	case '(':
+		return skipNested(input, i+1, ')')
+	}
+	return i + 1, nil
+}
+
+// skipNested пропускает конструкцию от открывающей скобки в позиции i
+// до парной закрывающей close с учётом кавычек и вложенных подстановок
+func skipNested(input string, i int, closing byte) (int, error) {
+	open := input[i]
+	depth := 0
+	for i < len(input) {
+		switch c := input[i]; c {
+		case open:
+			depth++
+			i++
+		case closing:
+			depth--
+			i++
+			if depth == 0 {
+				return i, nil
+			}
+		case '\\':
+			i += 2
+		case '\'':
+			end := strings.IndexByte(input[i+1:], '\'')
+			if end < 0 {
+				return 0, fmt.Errorf("%w while looking for matching `''", ErrIncomplete)
+			}
+			i += end + 2
+		case '"':
+			next, err := skipDoubleQuoted(input, i)
+			if err != nil {
+				return 0, err
+			}
+			i = next
+		case '$':
+			next, err := skipDollar(input, i)
+			if err != nil {
+				return 0, err
+			}
+			i = next
+		case '`':
+			next, err := skipBackquoted(input, i)
+			if err != nil {
+				return 0, err
+			}
+			i = next
+		default:
+			i++
+		}
+	}
+	return 0, fmt.Errorf("%w while looking for matching `%c'", ErrIncomplete, closing)
+}
+
+// skipBackquoted пропускает подстановку команды в обратных кавычках
+func skipBackquoted(input string, i int) (int, error) {
+	for i++; i < len(input); i++ {
+		switch input[i] {
+		case '\\':
+			i++
+		case '`':
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("%w while looking for matching ``'", ErrIncomplete)
+}
@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// tokenText записывает лексемы как kind:value через пробел, без EOF
+func tokenText(tokens []Token) string {
+	var parts []string
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case TokEOF:
+		case TokWord:
+			parts = append(parts, "w:"+tok.Value)
+		case TokIONumber:
+			parts = append(parts, "n:"+tok.Value)
+		case TokNewline:
+			parts = append(parts, "nl")
+		default:
+			parts = append(parts, tok.Kind.String())
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Слова остаются в исходном виде вместе с кавычками
+		{`echo hello   world`, `w:echo w:hello w:world`},
+		{`echo "a b" 'c d' e\ f`, `w:echo w:"a b" w:'c d' w:e\ f`},
+		{`echo "a'b" 'a"b' "x\"y"`, `w:echo w:"a'b" w:'a"b' w:"x\"y"`},
+		{`echo a"b c"d`, `w:echo w:a"b c"d`},
+		{`echo "a|b;c" 'x&y' a\|b`, `w:echo w:"a|b;c" w:'x&y' w:a\|b`},
+		{`echo $(a ")" b) ${x:-y z}w`, `w:echo w:$(a ")" b) w:${x:-y z}w`},
+		{"echo `a b` $((1 + 2))", "w:echo w:`a b` w:$((1 + 2))"},
+		{"echo a\\\nb", `w:echo w:a\` + "\n" + `b`},
+		{"echo a \\\n b", `w:echo w:a w:b`},
+
+		// Комментарии
+		{`echo a # comment`, `w:echo w:a`},
+		{`echo a#b`, `w:echo w:a#b`},
+		{"# whole line\necho x", `nl w:echo w:x`},
+
+		// Операторы: длинные раньше коротких
+		{`a|b||c&&d;e&f`, `w:a | w:b || w:c && w:d ; w:e & w:f`},
+		{`x) a;; y) b`, `w:x ) w:a ;; w:y ) w:b`},
+		{`(a) {b}`, `( w:a ) w:{b}`},
+		{`a<f >g >>h`, `w:a < w:f > w:g >> w:h`},
+		{`a <>f >|g &>h &>>i`, `w:a <> w:f >| w:g &> w:h &>> w:i`},
+		{`a <&3 >&- <<<str`, `w:a <& w:3 >& w:- <<< w:str`},
+		{"a\nb", `w:a nl w:b`},
+
+		// Номера дескрипторов — только цифры вплотную перед < или >
+		{`cmd 2>err 10<in`, `w:cmd n:2 > w:err n:10 < w:in`},
+		{`cmd 2>&1 1>&-`, `w:cmd n:2 >& w:1 n:1 >& w:-`},
+		{`cmd a2>f 2 >f`, `w:cmd w:a2 > w:f w:2 > w:f`},
+		{`echo 2`, `w:echo w:2`},
+
+		// Подстановка процесса начинает слово
+		{`diff <(sort a) <(sort b)`, `w:diff w:<(sort a) w:<(sort b)`},
+		{`tee >(gzip > out.gz)x`, `w:tee w:>(gzip > out.gz)x`},
+		{`cat < <(ls)`, `w:cat < w:<(ls)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex(%q) error = %v", tt.input, err)
+			}
+			if got := tokenText(tokens); got != tt.want {
+				t.Errorf("Lex(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexQuoted(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []bool
+	}{
+		{`if "if" \if 'if' i"f"`, []bool{false, true, true, true, true}},
+		{`echo $x ${y}`, []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		tokens, err := Lex(tt.input)
+		if err != nil {
+			t.Fatalf("Lex(%q) error = %v", tt.input, err)
+		}
+		var got []bool
+		for _, tok := range tokens {
+			if tok.Kind == TokWord {
+				got = append(got, tok.Quoted)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lex(%q) quoted = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLexHeredoc(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		bodies []string
+		tokens string
+	}{
+		{
+			name:   "plain",
+			input:  "cat <<EOF\nline $x\n  indented\nEOF\necho after",
+			bodies: []string{"line $x\n  indented\n"},
+			tokens: `w:cat << w:EOF nl w:echo w:after`,
+		},
+		{
+			name:   "strip tabs",
+			input:  "cat <<-END\n\tone\n\t\ttwo\n\tEND\n",
+			bodies: []string{"one\ntwo\n"},
+			tokens: `w:cat <<- w:END nl`,
+		},
+		{
+			name:   "quoted delimiter",
+			input:  "cat <<'E O'\n$x\nE O\n",
+			bodies: []string{"$x\n"},
+			tokens: `w:cat << w:'E O' nl`,
+		},
+		{
+			name:   "two documents",
+			input:  "cat <<A; cat <<B\na\nA\nb\nB\n",
+			bodies: []string{"a\n", "b\n"},
+			tokens: `w:cat << w:A ; w:cat << w:B nl`,
+		},
+		{
+			name:   "empty body at end of input",
+			input:  "cat <<EOF\nEOF",
+			bodies: []string{""},
+			tokens: `w:cat << w:EOF nl`,
+		},
+		{
+			name:   "delimiter must match whole line",
+			input:  "cat <<EOF\n EOF\nEOFx\nEOF\n",
+			bodies: []string{" EOF\nEOFx\n"},
+			tokens: `w:cat << w:EOF nl`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := Lex(tt.input)
+			if err != nil {
+				t.Fatalf("Lex(%q) error = %v", tt.input, err)
+			}
+			var bodies []string
+			for _, tok := range tokens {
+				if tok.Kind == TokDLess || tok.Kind == TokDLessDash {
+					bodies = append(bodies, tok.Body)
+				}
+			}
+			if !reflect.DeepEqual(bodies, tt.bodies) {
+				t.Errorf("Lex(%q) bodies = %q, want %q", tt.input, bodies, tt.bodies)
+			}
+			if got := tokenText(tokens); got != tt.tokens {
+				t.Errorf("Lex(%q) = %s, want %s", tt.input, got, tt.tokens)
+			}
+		})
+	}
+}
+
+func TestLexIncomplete(t *testing.T) {
+	inputs := []string{
+		`echo "abc`,
+		`echo 'abc`,
+		`echo "a $(b"`,
+		`echo $(foo`,
+		`echo ${x`,
+		"echo `date",
+		`echo \`,
+		"echo a\\\n",
+		"cat <<EOF\nbody",
+		"cat <<EOF",
+		`diff <(sort a`,
+	}
+	for _, input := range inputs {
+		if _, err := Lex(input); !errors.Is(err, ErrIncomplete) {
+			t.Errorf("Lex(%q) error = %v, want ErrIncomplete", input, err)
+		}
+	}
+}
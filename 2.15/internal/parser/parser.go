@@ -2,89 +2,655 @@ package parser
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
-
-	"wb-l2/internal/model"
 )
 
-// Prompt возвращает строку приглашения
-func Prompt() string {
-	cwd, _ := os.Getwd()
-	return fmt.Sprintf("mini-sh:%s$ ", filepath.Base(cwd))
+// Parse разбирает строку в AST
+func Parse(line string) (*List, error) {
+	return ParseAliases(line, nil)
 }
 
-// SplitPreserve делит строку по сепаратору без регэкспов
-func SplitPreserve(s, sep string) []string {
-	if !strings.Contains(s, sep) {
-		return []string{s}
+// ParseAliases разбирает строку в AST, заменяя слово в позиции команды
+// его псевдонимом из aliases
+func ParseAliases(line string, aliases map[string]string) (*List, error) {
+	tokens, err := Lex(line)
+	if err != nil {
+		return nil, err
 	}
-	var parts []string
-	cur := s
-	for {
-		idx := strings.Index(cur, sep)
-		if idx < 0 {
-			parts = append(parts, cur)
-			break
+	p := &parser{input: line, tokens: tokens, aliases: aliases, checked: -1, aliasNext: -1}
+	list, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.Kind != TokEOF {
+		return nil, unexpected(tok)
+	}
+	return list, nil
+}
+
+type parser struct {
+	input  string
+	tokens []Token
+	pos    int
+	// end — конец последней прочитанной лексемы
+	end int
+
+	aliases map[string]string
+	// checked — индекс лексемы, уже проверенной на псевдоним; aliasNext — индекс
+	// слова после псевдонима, значение которого оканчивается пробелом:
+	// такое слово тоже проверяется
+	checked   int
+	aliasNext int
+}
+
+func (p *parser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() Token {
+	tok := p.tokens[p.pos]
+	if tok.Kind != TokEOF {
+		p.pos++
+		p.end = tok.End
+	}
+	return tok
+}
+
+func unexpected(tok Token) error {
+	if tok.Kind == TokEOF {
+		return fmt.Errorf("syntax error: %w", ErrIncomplete)
+	}
+	if tok.Kind == TokWord {
+		return fmt.Errorf("syntax error near unexpected token `%s'", tok.Value)
+	}
+	return fmt.Errorf("syntax error near unexpected token `%s'", tok.Kind)
+}
+
+// isKeyword сообщает, является ли текущая лексема зарезервированным словом word
+func (p *parser) isKeyword(word string) bool {
+	tok := p.peek()
+	return tok.Kind == TokWord && !tok.Quoted && tok.Value == word
+}
+
+// expect читает обязательное зарезервированное слово
+func (p *parser) expect(word string) error {
+	if !p.isKeyword(word) {
+		return unexpected(p.peek())
+	}
+	p.next()
+	return nil
+}
+
+// atListEnd сообщает, что список закончился: ввод исчерпан или встретилось
+// закрывающее слово конструкции, ) или ;;
+func (p *parser) atListEnd() bool {
+	tok := p.peek()
+	switch tok.Kind {
+	case TokEOF, TokRParen, TokDSemi:
+		return true
+	case TokWord:
+		if tok.Quoted {
+			return false
 		}
-		parts = append(parts, cur[:idx])
-		cur = cur[idx+len(sep):]
+		switch tok.Value {
+		case "then", "elif", "else", "fi", "do", "done", "esac", "}":
+			return true
+		}
+	}
+	return false
+}
+
+// skipNewlines пропускает переводы строк, например после | или &&
+func (p *parser) skipNewlines() {
+	for p.peek().Kind == TokNewline {
+		p.next()
 	}
-	return parts
 }
 
-// ParsePipeline парсит пайплайн команды в список стадий
-func ParsePipeline(line string) ([]model.Command, error) {
-	stages := SplitPreserve(line, "|")
-	cmds := make([]model.Command, 0, len(stages))
-	for _, st := range stages {
-		st = strings.TrimSpace(st)
-		if st == "" {
-			return nil, fmt.Errorf("empty stage in pipeline")
+// parseList: and_or ((';' | '&' | newline) and_or)* [';' | '&' | newline]
+func (p *parser) parseList() (*List, error) {
+	list := &List{}
+	for p.skipNewlines(); !p.atListEnd(); p.skipNewlines() {
+		andOr, err := p.parseAndOr()
+		if err != nil {
+			return nil, err
+		}
+		item := &ListItem{AndOr: andOr}
+		list.Items = append(list.Items, item)
+
+		switch p.peek().Kind {
+		case TokSemi, TokNewline:
+			p.next()
+		case TokAmp:
+			p.next()
+			item.Background = true
+		default:
+			if !p.atListEnd() {
+				return nil, unexpected(p.peek())
+			}
 		}
-		cmd, err := parseRedirections(st)
+	}
+	return list, nil
+}
+
+// parseBody разбирает непустой список внутри конструкции
+func (p *parser) parseBody() (*List, error) {
+	list, err := p.parseList()
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, unexpected(p.peek())
+	}
+	return list, nil
+}
+
+// parseAndOr: pipeline (('&&' | '||') pipeline)*
+func (p *parser) parseAndOr() (*AndOr, error) {
+	start := p.peek().Pos
+	first, err := p.parsePipeline()
+	if err != nil {
+		return nil, err
+	}
+	andOr := &AndOr{Pipelines: []*Pipeline{first}}
+	for p.peek().Kind == TokAndIf || p.peek().Kind == TokOrIf {
+		op := p.next().Kind
+		p.skipNewlines()
+		pl, err := p.parsePipeline()
+		if err != nil {
+			return nil, err
+		}
+		andOr.Ops = append(andOr.Ops, op)
+		andOr.Pipelines = append(andOr.Pipelines, pl)
+	}
+	andOr.Text = p.text(start, p.end)
+	return andOr, nil
+}
+
+// parsePipeline: ['!'] command ('|' command)*
+func (p *parser) parsePipeline() (*Pipeline, error) {
+	pl := &Pipeline{}
+	if p.isKeyword("!") {
+		p.next()
+		pl.Negate = true
+	}
+	for {
+		cmd, err := p.parseCommand()
 		if err != nil {
 			return nil, err
 		}
-		if len(cmd.Args) == 0 {
-			return nil, fmt.Errorf("empty command")
+		pl.Commands = append(pl.Commands, cmd)
+		if p.peek().Kind != TokPipe {
+			return pl, nil
+		}
+		p.next()
+		p.skipNewlines()
+	}
+}
+
+// parseCommand: compound_command redirect* | function_definition | simple_command
+func (p *parser) parseCommand() (Command, error) {
+	// псевдоним может раскрыться в начало составной команды
+	p.expandAlias()
+	start := p.peek().Pos
+	var (
+		cmd  Command
+		base *Compound
+		err  error
+	)
+	switch {
+	case p.peek().Kind == TokLParen:
+		c := &Subshell{}
+		cmd, base, err = c, &c.Compound, p.parseSubshell(c)
+	case p.isKeyword("if"):
+		c := &IfClause{}
+		cmd, base, err = c, &c.Compound, p.parseIf(c)
+	case p.isKeyword("while"), p.isKeyword("until"):
+		c := &WhileClause{}
+		cmd, base, err = c, &c.Compound, p.parseWhile(c)
+	case p.isKeyword("for"):
+		c := &ForClause{}
+		cmd, base, err = c, &c.Compound, p.parseFor(c)
+	case p.isKeyword("case"):
+		c := &CaseClause{}
+		cmd, base, err = c, &c.Compound, p.parseCase(c)
+	case p.isKeyword("{"):
+		c := &Group{}
+		cmd, base, err = c, &c.Compound, p.parseGroup(c)
+	case p.isKeyword("function") || p.isFuncDef():
+		c := &FuncDef{}
+		cmd, base, err = c, &c.Compound, p.parseFuncDef(c)
+	default:
+		return p.parseSimpleCommand()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for isRedirect(p.peek()) {
+		r, err := p.parseRedirect()
+		if err != nil {
+			return nil, err
 		}
-		cmds = append(cmds, cmd)
+		base.Redirects = append(base.Redirects, r)
 	}
-	return cmds, nil
+	base.Text = p.text(start, p.end)
+	return cmd, nil
 }
 
-// parseRedirections обрабатывает редиректы <, >, >>
-func parseRedirections(s string) (model.Command, error) {
-	var cmd model.Command
-	tokens := strings.Fields(s)
-	for i := 0; i < len(tokens); i++ {
-		t := tokens[i]
-		switch t {
-		case ">":
-			if i+1 >= len(tokens) {
-				return cmd, fmt.Errorf("redirect > requires filename")
+// parseSimpleCommand: (assignment | redirect)* (word | redirect)*
+func (p *parser) parseSimpleCommand() (Command, error) {
+	cmd := &SimpleCommand{}
+	for {
+		tok := p.peek()
+		if tok.Kind == TokWord && (p.pos == p.aliasNext || len(cmd.Words) == 0) {
+			if _, _, ok := splitAssign(tok.Value); !ok || len(cmd.Words) > 0 {
+				p.expandAlias()
+				tok = p.peek()
 			}
-			cmd.OutFile = tokens[i+1]
-			cmd.AppendOut = false
-			i++
-		case ">>":
-			if i+1 >= len(tokens) {
-				return cmd, fmt.Errorf("redirect >> requires filename")
+		}
+		switch {
+		case tok.Kind == TokWord:
+			p.next()
+			if name, value, ok := splitAssign(tok.Value); ok && len(cmd.Words) == 0 {
+				cmd.Assigns = append(cmd.Assigns, Assign{Name: name, Value: Word{Raw: value}})
+				continue
 			}
-			cmd.OutFile = tokens[i+1]
-			cmd.AppendOut = true
-			i++
-		case "<":
-			if i+1 >= len(tokens) {
-				return cmd, fmt.Errorf("redirect < requires filename")
+			cmd.Words = append(cmd.Words, Word{Raw: tok.Value})
+		case isRedirect(tok):
+			r, err := p.parseRedirect()
+			if err != nil {
+				return nil, err
 			}
-			cmd.InFile = tokens[i+1]
-			i++
+			cmd.Redirects = append(cmd.Redirects, r)
 		default:
-			cmd.Args = append(cmd.Args, t)
+			if len(cmd.Words) == 0 && len(cmd.Assigns) == 0 && len(cmd.Redirects) == 0 {
+				return nil, unexpected(tok)
+			}
+			return cmd, nil
 		}
 	}
-	return cmd, nil
+}
+
+// expandAlias заменяет текущее слово значением псевдонима, пока оно
+// является псевдонимом. Псевдоним не раскрывается повторно внутри
+// собственного значения, поэтому alias ls='ls -F' не зацикливается.
+// Лексемы значения получают позицию заменённого слова, так что исходный
+// текст команды содержит имя псевдонима
+func (p *parser) expandAlias() {
+	if p.pos == p.checked || len(p.aliases) == 0 {
+		return
+	}
+	p.checked = p.pos
+	seen := make(map[string]bool)
+	next := -1
+	for {
+		tok := p.peek()
+		if tok.Kind != TokWord || tok.Quoted || keywords[tok.Value] || seen[tok.Value] {
+			break
+		}
+		value, ok := p.aliases[tok.Value]
+		if !ok {
+			break
+		}
+		seen[tok.Value] = true
+		tokens, err := Lex(value)
+		if err != nil {
+			break
+		}
+		tokens = tokens[:len(tokens)-1]
+		for i := range tokens {
+			tokens[i].Pos, tokens[i].End = tok.Pos, tok.End
+			tokens[i].BodyStart, tokens[i].BodyEnd = 0, 0
+		}
+		p.tokens = slices.Concat(p.tokens[:p.pos], tokens, p.tokens[p.pos+1:])
+		if next >= 0 {
+			next += len(tokens) - 1
+		}
+		if strings.HasSuffix(value, " ") || strings.HasSuffix(value, "\t") {
+			next = p.pos + len(tokens)
+		}
+	}
+	if next >= 0 {
+		p.aliasNext = next
+	}
+}
+
+// splitAssign распознаёт слово вида NAME=value; имя не может содержать кавычек
+func splitAssign(word string) (string, string, bool) {
+	eq := strings.IndexByte(word, '=')
+	if eq <= 0 || !IsName(word[:eq]) {
+		return "", "", false
+	}
+	return word[:eq], word[eq+1:], true
+}
+
+// isRedirect сообщает, начинается ли с лексемы tok перенаправление
+func isRedirect(tok Token) bool {
+	switch tok.Kind {
+	case TokLess, TokGreat, TokDGreat, TokDLess, TokDLessDash, TokTLess,
+		TokLessAnd, TokGreatAnd, TokLessGreat, TokClobber, TokAndGreat, TokAndDGreat, TokIONumber:
+		return true
+	}
+	return false
+}
+
+// parseRedirect: [io_number] redirect_op word
+func (p *parser) parseRedirect() (Redirect, error) {
+	r := Redirect{Fd: -1}
+	if p.peek().Kind == TokIONumber {
+		fd, err := strconv.Atoi(p.next().Value)
+		if err != nil {
+			return r, fmt.Errorf("%s: bad file descriptor", p.tokens[p.pos-1].Value)
+		}
+		r.Fd = fd
+	}
+	op := p.next()
+	target := p.next()
+	if target.Kind != TokWord {
+		if target.Kind == TokEOF {
+			return r, unexpected(target)
+		}
+		return r, fmt.Errorf("syntax error near unexpected token `%s'", target.Kind)
+	}
+	if r.Fd >= 0 && (op.Kind == TokAndGreat || op.Kind == TokAndDGreat) {
+		return r, fmt.Errorf("syntax error near unexpected token `%s'", op.Kind)
+	}
+	r.Op = op.Kind
+	r.Target = Word{Raw: target.Value}
+	r.Body = op.Body
+	return r, nil
+}
+
+// text возвращает исходный текст от start до end. Тела here-document,
+// начатых в этом тексте, лежат после конца строки; они дописываются,
+// чтобы текст оставался самостоятельным скриптом для дочернего шелла
+func (p *parser) text(start, end int) string {
+	text := p.input[start:end]
+	bodyStart, bodyEnd := -1, -1
+	for _, tok := range p.tokens {
+		if tok.Pos < start || tok.Pos >= end || tok.BodyEnd == 0 || tok.BodyStart < end {
+			continue
+		}
+		if bodyStart < 0 || tok.BodyStart < bodyStart {
+			bodyStart = tok.BodyStart
+		}
+		bodyEnd = max(bodyEnd, tok.BodyEnd)
+	}
+	if bodyStart >= 0 {
+		text += "\n" + p.input[bodyStart:bodyEnd]
+	}
+	return text
+}
+
+// parseIf: if list then list (elif list then list)* [else list] fi
+func (p *parser) parseIf(c *IfClause) error {
+	p.next()
+	for {
+		cond, err := p.parseBody()
+		if err != nil {
+			return err
+		}
+		if err := p.expect("then"); err != nil {
+			return err
+		}
+		body, err := p.parseBody()
+		if err != nil {
+			return err
+		}
+		c.Conds = append(c.Conds, cond)
+		c.Bodies = append(c.Bodies, body)
+
+		if !p.isKeyword("elif") {
+			break
+		}
+		p.next()
+	}
+	if p.isKeyword("else") {
+		p.next()
+		body, err := p.parseBody()
+		if err != nil {
+			return err
+		}
+		c.Else = body
+	}
+	return p.expect("fi")
+}
+
+// parseWhile: (while | until) list do list done
+func (p *parser) parseWhile(c *WhileClause) error {
+	c.Until = p.next().Value == "until"
+	cond, err := p.parseBody()
+	if err != nil {
+		return err
+	}
+	body, err := p.parseDoGroup()
+	if err != nil {
+		return err
+	}
+	c.Cond, c.Body = cond, body
+	return nil
+}
+
+// parseDoGroup: do list done
+func (p *parser) parseDoGroup() (*List, error) {
+	if err := p.expect("do"); err != nil {
+		return nil, err
+	}
+	body, err := p.parseBody()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("done"); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// parseFor: for name [in word*] (';' | newline)* do list done
+func (p *parser) parseFor(c *ForClause) error {
+	p.next()
+	name := p.next()
+	if name.Kind != TokWord || !IsName(name.Value) {
+		if name.Kind == TokWord {
+			return fmt.Errorf("`%s': not a valid identifier", name.Value)
+		}
+		return unexpected(name)
+	}
+	c.Var = name.Value
+
+	p.skipNewlines()
+	if p.isKeyword("in") {
+		p.next()
+		c.HasIn = true
+		for p.peek().Kind == TokWord {
+			tok := p.next()
+			c.Words = append(c.Words, Word{Raw: tok.Value})
+		}
+		if k := p.peek().Kind; k != TokSemi && k != TokNewline {
+			return unexpected(p.peek())
+		}
+	}
+	if p.peek().Kind == TokSemi {
+		p.next()
+	}
+	p.skipNewlines()
+
+	body, err := p.parseDoGroup()
+	if err != nil {
+		return err
+	}
+	c.Body = body
+	return nil
+}
+
+// parseCase: case word in (['('] pattern ('|' pattern)* ')' list [';;'])* esac
+func (p *parser) parseCase(c *CaseClause) error {
+	p.next()
+	word := p.next()
+	if word.Kind != TokWord {
+		return unexpected(word)
+	}
+	c.Word = Word{Raw: word.Value}
+	p.skipNewlines()
+	if err := p.expect("in"); err != nil {
+		return err
+	}
+
+	for p.skipNewlines(); !p.isKeyword("esac"); p.skipNewlines() {
+		item := &CaseItem{}
+		if p.peek().Kind == TokLParen {
+			p.next()
+		}
+		for {
+			tok := p.next()
+			if tok.Kind != TokWord {
+				return unexpected(tok)
+			}
+			item.Patterns = append(item.Patterns, Word{Raw: tok.Value})
+			if p.peek().Kind != TokPipe {
+				break
+			}
+			p.next()
+		}
+		if tok := p.next(); tok.Kind != TokRParen {
+			return unexpected(tok)
+		}
+
+		body, err := p.parseList()
+		if err != nil {
+			return err
+		}
+		item.Body = body
+		c.Items = append(c.Items, item)
+
+		if p.peek().Kind == TokDSemi {
+			p.next()
+			continue
+		}
+		// после последней ветки ;; можно опустить
+		p.skipNewlines()
+		if !p.isKeyword("esac") {
+			return unexpected(p.peek())
+		}
+	}
+	p.next()
+	return nil
+}
+
+// parseGroup: '{' list '}'
+func (p *parser) parseGroup(c *Group) error {
+	p.next()
+	body, err := p.parseBody()
+	if err != nil {
+		return err
+	}
+	c.Body = body
+	return p.expect("}")
+}
+
+// parseSubshell: '(' list ')'
+func (p *parser) parseSubshell(c *Subshell) error {
+	start := p.next().End
+	body, err := p.parseBody()
+	if err != nil {
+		return err
+	}
+	c.Body = body
+	c.Script = p.text(start, p.peek().Pos)
+	if tok := p.next(); tok.Kind != TokRParen {
+		return unexpected(tok)
+	}
+	return nil
+}
+
+// isFuncDef сообщает, что с текущей лексемы начинается определение name()
+func (p *parser) isFuncDef() bool {
+	tok := p.peek()
+	if tok.Kind != TokWord || tok.Quoted || keywords[tok.Value] || p.pos+2 >= len(p.tokens) {
+		return false
+	}
+	return p.tokens[p.pos+1].Kind == TokLParen && p.tokens[p.pos+2].Kind == TokRParen
+}
+
+// parseFuncDef: name '(' ')' newline* compound_command
+// | function name ['(' ')'] newline* compound_command
+func (p *parser) parseFuncDef(c *FuncDef) error {
+	if p.isKeyword("function") {
+		p.next()
+	}
+	name := p.next()
+	if name.Kind != TokWord {
+		return unexpected(name)
+	}
+	if name.Quoted || !isFuncName(name.Value) {
+		return fmt.Errorf("`%s': not a valid identifier", name.Value)
+	}
+	c.Name = name.Value
+	if p.peek().Kind == TokLParen {
+		p.next()
+		if tok := p.next(); tok.Kind != TokRParen {
+			return unexpected(tok)
+		}
+	}
+	p.skipNewlines()
+
+	// тело функции — составная команда, а не простая
+	switch tok := p.peek(); {
+	case tok.Kind == TokLParen:
+	case tok.Kind == TokWord && !tok.Quoted && compoundStart[tok.Value]:
+	default:
+		return unexpected(tok)
+	}
+	body, err := p.parseCommand()
+	if err != nil {
+		return err
+	}
+	c.Body = body
+	return nil
+}
+
+// keywords — зарезервированные слова; они не бывают псевдонимами и именами функций
+var keywords = map[string]bool{
+	"if": true, "then": true, "elif": true, "else": true, "fi": true,
+	"while": true, "until": true, "for": true, "in": true, "do": true, "done": true,
+	"case": true, "esac": true, "{": true, "}": true, "!": true, "function": true,
+}
+
+// IsKeyword сообщает, является ли слово зарезервированным словом шелла
+func IsKeyword(s string) bool {
+	return keywords[s]
+}
+
+// compoundStart — слова, с которых начинается составная команда
+var compoundStart = map[string]bool{"{": true, "if": true, "while": true, "until": true, "for": true, "case": true}
+
+// isFuncName сообщает, допустимо ли имя функции: кроме букв, цифр и _
+// разрешены - . и :, но не $, кавычки, = и /
+func isFuncName(s string) bool {
+	if s == "" || keywords[s] || isDigits(s) {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || r == '-' || r == '.' || r == ':' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsName сообщает, является ли строка допустимым именем переменной
+func IsName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || (i > 0 && '0' <= r && r <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
 }
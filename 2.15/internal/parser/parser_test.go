@@ -0,0 +1,266 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// dump записывает AST в компактном виде, удобном для сравнения:
+// простая команда — [A=1 слова перенаправления], составные — ключевое
+// слово со списками в фигурных скобках
+func dump(list *List) string {
+	var items []string
+	for _, item := range list.Items {
+		text := dumpAndOr(item.AndOr)
+		if item.Background {
+			text += " &"
+		}
+		items = append(items, text)
+	}
+	return strings.Join(items, "; ")
+}
+
+func dumpAndOr(andOr *AndOr) string {
+	text := dumpPipeline(andOr.Pipelines[0])
+	for i, op := range andOr.Ops {
+		text += " " + op.String() + " " + dumpPipeline(andOr.Pipelines[i+1])
+	}
+	return text
+}
+
+func dumpPipeline(pl *Pipeline) string {
+	stages := make([]string, len(pl.Commands))
+	for i, c := range pl.Commands {
+		stages[i] = dumpCommand(c)
+	}
+	text := strings.Join(stages, " | ")
+	if pl.Negate {
+		text = "! " + text
+	}
+	return text
+}
+
+func dumpWords(words []Word) string {
+	raw := make([]string, len(words))
+	for i, w := range words {
+		raw[i] = w.Raw
+	}
+	return strings.Join(raw, " ")
+}
+
+func dumpRedirects(rs []Redirect) string {
+	var text string
+	for _, r := range rs {
+		text += " " + r.String()
+		if r.Body != "" {
+			text += fmt.Sprintf("%q", r.Body)
+		}
+	}
+	return text
+}
+
+func dumpCommand(c Command) string {
+	switch c := c.(type) {
+	case *SimpleCommand:
+		var parts []string
+		for _, a := range c.Assigns {
+			parts = append(parts, a.Name+"="+a.Value.Raw)
+		}
+		if len(c.Words) > 0 {
+			parts = append(parts, dumpWords(c.Words))
+		}
+		return "[" + strings.Join(parts, " ") + dumpRedirects(c.Redirects) + "]"
+	case *IfClause:
+		var text string
+		for i := range c.Conds {
+			kw := "if"
+			if i > 0 {
+				kw = " elif"
+			}
+			text += fmt.Sprintf("%s{%s} then{%s}", kw, dump(c.Conds[i]), dump(c.Bodies[i]))
+		}
+		if c.Else != nil {
+			text += " else{" + dump(c.Else) + "}"
+		}
+		return text + dumpRedirects(c.Redirects)
+	case *WhileClause:
+		kw := "while"
+		if c.Until {
+			kw = "until"
+		}
+		return fmt.Sprintf("%s{%s} do{%s}%s", kw, dump(c.Cond), dump(c.Body), dumpRedirects(c.Redirects))
+	case *ForClause:
+		in := ""
+		if c.HasIn {
+			in = " in(" + dumpWords(c.Words) + ")"
+		}
+		return fmt.Sprintf("for %s%s do{%s}%s", c.Var, in, dump(c.Body), dumpRedirects(c.Redirects))
+	case *CaseClause:
+		text := "case " + c.Word.Raw
+		for _, item := range c.Items {
+			pats := make([]string, len(item.Patterns))
+			for i, p := range item.Patterns {
+				pats[i] = p.Raw
+			}
+			text += fmt.Sprintf(" %s){%s}", strings.Join(pats, "|"), dump(item.Body))
+		}
+		return text + dumpRedirects(c.Redirects)
+	case *Group:
+		return "{" + dump(c.Body) + "}" + dumpRedirects(c.Redirects)
+	case *Subshell:
+		return "(" + dump(c.Body) + ")" + dumpRedirects(c.Redirects)
+	case *FuncDef:
+		return c.Name + "()" + dumpCommand(c.Body)
+	}
+	return fmt.Sprintf("%T", c)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Простые команды, присваивания и перенаправления
+		{`echo a b`, `[echo a b]`},
+		{`A=1 B="x y" cmd arg`, `[A=1 B="x y" cmd arg]`},
+		{`A=1`, `[A=1]`},
+		{`cmd A=1`, `[cmd A=1]`},
+		{`=x cmd`, `[=x cmd]`},
+		{`cmd <in >out 2>>log 2>&1`, `[cmd <in >out 2>>log 2>&1]`},
+		{`>file`, `[ >file]`},
+		{`cat <<<"here"`, `[cat <<<"here"]`},
+		{"cat <<EOF\nbody\nEOF", `[cat <<EOF"body\n"]`},
+		{`cat < <(ls)`, `[cat < <(ls)]`},
+
+		// Списки и пайплайны
+		{`a; b & c`, `[a]; [b] &; [c]`},
+		{"a\n\nb\n", `[a]; [b]`},
+		{`a | b | c`, `[a] | [b] | [c]`},
+		{`a && b || ! c | d`, `[a] && [b] || ! [c] | [d]`},
+		{"a &&\n b", `[a] && [b]`},
+		{"a |\n b", `[a] | [b]`},
+
+		// Составные команды
+		{`if a; then b; fi`, `if{[a]} then{[b]}`},
+		{`if a; then b; elif c; then d; else e; fi`, `if{[a]} then{[b]} elif{[c]} then{[d]} else{[e]}`},
+		{"if a\nthen\n  b\nfi", `if{[a]} then{[b]}`},
+		{`while a; do b; c; done`, `while{[a]} do{[b]; [c]}`},
+		{`until a; do b; done >log`, `until{[a]} do{[b]} >log`},
+		{`for i in 1 "2 3" $x; do echo $i; done`, `for i in(1 "2 3" $x) do{[echo $i]}`},
+		{`for i; do b; done`, `for i do{[b]}`},
+		{"for i in\ndo b; done", `for i in() do{[b]}`},
+		{`case $x in a|b) one;; (c) two;; *) ;; esac`, `case $x a|b){[one]} c){[two]} *){}`},
+		{"case x in\n a) one\nesac", `case x a){[one]}`},
+		{`{ a; b; } 2>err`, `{[a]; [b]} 2>err`},
+		{`(a; b) | c`, `([a]; [b]) | [c]`},
+		{`f() { a; }`, `f(){[a]}`},
+		{"function g { a; }", `g(){[a]}`},
+		{"h()\n(a)", `h()([a])`},
+		{`while read l; do echo $l; done < <(ls)`, `while{[read l]} do{[echo $l]} < <(ls)`},
+
+		// Зарезервированные слова действуют только в позиции команды и без кавычек
+		{`echo if then fi`, `[echo if then fi]`},
+		{`"if" a`, `["if" a]`},
+		{`{a}`, `[{a}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			list, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got := dump(list); got != tt.want {
+				t.Errorf("Parse(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAliases(t *testing.T) {
+	aliases := map[string]string{"ll": "ls -l", "sudo": "sudo ", "e": "echo"}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`ll /tmp`, `[ls -l /tmp]`},
+		{`echo ll`, `[echo ll]`},
+		{`a | ll`, `[a] | [ls -l]`},
+		{`sudo ll`, `[sudo ls -l]`},
+		{`\ll`, `[\ll]`},
+		{`e e`, `[echo e]`},
+	}
+	for _, tt := range tests {
+		list, err := ParseAliases(tt.input, aliases)
+		if err != nil {
+			t.Fatalf("ParseAliases(%q) error = %v", tt.input, err)
+		}
+		if got := dump(list); got != tt.want {
+			t.Errorf("ParseAliases(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseSubshellScript(t *testing.T) {
+	list, err := Parse("(cat <<EOF\nbody\nEOF\n) >out")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	sub, ok := list.Items[0].AndOr.Pipelines[0].Commands[0].(*Subshell)
+	if !ok {
+		t.Fatalf("command = %T, want *Subshell", list.Items[0].AndOr.Pipelines[0].Commands[0])
+	}
+	if want := "cat <<EOF\nbody\nEOF\n"; sub.Script != want {
+		t.Errorf("Script = %q, want %q", sub.Script, want)
+	}
+}
+
+func TestParseIncomplete(t *testing.T) {
+	inputs := []string{
+		`echo "abc`,
+		`a |`,
+		`a &&`,
+		`a ||`,
+		`if a; then b`,
+		`if a; then b; else`,
+		`while a; do b`,
+		`for i in 1 2; do`,
+		`case x in a) b;;`,
+		`{ a;`,
+		`(a`,
+		`f() {`,
+		`echo >`,
+	}
+	for _, input := range inputs {
+		if _, err := Parse(input); !errors.Is(err, ErrIncomplete) {
+			t.Errorf("Parse(%q) error = %v, want ErrIncomplete", input, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`a | | b`, "syntax error near unexpected token `|'"},
+		{`; a`, "syntax error near unexpected token `;'"},
+		{`a )`, "syntax error near unexpected token `)'"},
+		{`fi`, "syntax error near unexpected token `fi'"},
+		{`if a; fi`, "syntax error near unexpected token `fi'"},
+		{`for 1x in a; do b; done`, "`1x': not a valid identifier"},
+		{`echo > | a`, "syntax error near unexpected token `|'"},
+		{`f() echo`, "syntax error near unexpected token `echo'"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		if err == nil {
+			t.Errorf("Parse(%q) error = nil, want %q", tt.input, tt.want)
+			continue
+		}
+		if errors.Is(err, ErrIncomplete) || err.Error() != tt.want {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
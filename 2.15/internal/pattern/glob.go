@@ -0,0 +1,165 @@
+package pattern
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Glob возвращает отсортированный список путей, совпадающих с шаблоном.
+// Шаблон сопоставляется покомпонентно: * и ? не пересекают /, а компонент **
+// совпадает с любым числом вложенных каталогов. Имена, начинающиеся с точки,
+// совпадают, только если компонент шаблона сам начинается с точки.
+// Шаблон, оканчивающийся на /, совпадает только с каталогами.
+// Относительный шаблон раскрывается в каталоге dir, пути остаются относительными
+func Glob(pat, dir string) []string {
+	if pat == "" {
+		return nil
+	}
+	prefix := ""
+	if strings.HasPrefix(pat, "/") {
+		prefix = "/"
+	}
+	dirOnly := strings.HasSuffix(pat, "/")
+
+	var comps []string
+	for _, c := range strings.Split(pat, "/") {
+		if c != "" {
+			comps = append(comps, c)
+		}
+	}
+	if len(comps) == 0 {
+		return nil
+	}
+
+	g := globber{dir: dir}
+	var matches []string
+	for _, m := range g.glob(prefix, comps) {
+		if dirOnly {
+			if fi, err := os.Stat(g.abs(m)); err != nil || !fi.IsDir() {
+				continue
+			}
+			m += "/"
+		}
+		matches = append(matches, m)
+	}
+	sort.Strings(matches)
+	return dedup(matches)
+}
+
+// globber раскрывает шаблон; относительные пути отсчитываются от dir
+type globber struct {
+	dir string
+}
+
+// abs возвращает путь для обращения к файловой системе
+func (g globber) abs(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	if g.dir == "" {
+		g.dir = "."
+	}
+	return filepath.Join(g.dir, path)
+}
+
+// glob раскрывает оставшиеся компоненты шаблона относительно prefix
+func (g globber) glob(prefix string, comps []string) []string {
+	if len(comps) == 0 {
+		if _, err := os.Lstat(g.abs(prefix)); err != nil {
+			return nil
+		}
+		return []string{prefix}
+	}
+	comp, rest := comps[0], comps[1:]
+
+	if comp == "**" {
+		// ** совпадает с нулём каталогов, а затем с каждым подкаталогом;
+		// последним компонентом — со всеми файлами дерева. Символические
+		// ссылки на каталоги не обходятся, чтобы не зациклиться
+		var matches []string
+		if len(rest) > 0 {
+			matches = g.glob(prefix, rest)
+		}
+		for _, e := range g.readDir(prefix) {
+			if strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			path := join(prefix, e.Name())
+			if len(rest) == 0 {
+				matches = append(matches, path)
+			}
+			if e.IsDir() {
+				matches = append(matches, g.glob(path, comps)...)
+			}
+		}
+		return matches
+	}
+
+	if !HasMeta(comp) {
+		return g.glob(join(prefix, unescape(comp)), rest)
+	}
+
+	var matches []string
+	hidden := strings.HasPrefix(comp, ".") || strings.HasPrefix(comp, `\.`)
+	for _, e := range g.readDir(prefix) {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") && !hidden {
+			continue
+		}
+		if !Match(comp, name) {
+			continue
+		}
+		path := join(prefix, name)
+		if len(rest) == 0 {
+			matches = append(matches, path)
+			continue
+		}
+		if fi, err := os.Stat(g.abs(path)); err == nil && fi.IsDir() {
+			matches = append(matches, g.glob(path, rest)...)
+		}
+	}
+	return matches
+}
+
+func (g globber) readDir(dir string) []os.DirEntry {
+	entries, _ := os.ReadDir(g.abs(dir))
+	return entries
+}
+
+func join(prefix, name string) string {
+	switch {
+	case prefix == "":
+		return name
+	case strings.HasSuffix(prefix, "/"):
+		return prefix + name
+	default:
+		return prefix + "/" + name
+	}
+}
+
+// unescape снимает экранирование с компонента без метасимволов
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func dedup(s []string) []string {
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+package pattern
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// globTree создаёт во временном каталоге дерево файлов для Glob
+func globTree(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := []string{
+		"a.go", "b.go", "d.go", "sp ace.go", "c.txt", "]x", ".hidden.go", ".rc",
+		"sub/x.go", "sub/.secret/z.go", "sub/deep/y.go",
+	}
+	for _, name := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(root, "empty"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestGlob(t *testing.T) {
+	root := globTree(t)
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*.go", []string{"a.go", "b.go", "d.go", "sp ace.go"}},
+		{"?.go", []string{"a.go", "b.go", "d.go"}},
+		{"[ab].go", []string{"a.go", "b.go"}},
+		{"[!ab].go", []string{"d.go"}},
+		{"[]]*", []string{"]x"}},
+		{"*", []string{"]x", "a.go", "b.go", "c.txt", "d.go", "empty", "sp ace.go", "sub"}},
+
+		// Скрытые файлы совпадают, только если шаблон начинается с точки
+		{".*", []string{".hidden.go", ".rc"}},
+		{".*.go", []string{".hidden.go"}},
+		{`\.r*`, []string{".rc"}},
+		{"sub/*", []string{"sub/deep", "sub/x.go"}},
+		{"sub/.*/*", []string{"sub/.secret/z.go"}},
+
+		// Каталоги и **
+		{"*/", []string{"empty/", "sub/"}},
+		{"*/*.go", []string{"sub/x.go"}},
+		{"**/*.go", []string{"a.go", "b.go", "d.go", "sp ace.go", "sub/deep/y.go", "sub/x.go"}},
+		{"sub/**", []string{"sub/deep", "sub/deep/y.go", "sub/x.go"}},
+		{"**/y.go", []string{"sub/deep/y.go"}},
+
+		// Компоненты без метасимволов проверяются на существование
+		{"sub/deep/*", []string{"sub/deep/y.go"}},
+		{"nosuch/*", nil},
+
+		// Нет совпадений
+		{"*.rs", nil},
+		{"x*", nil},
+		{`\*.go`, nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := Glob(tt.pattern, root); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Glob(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlobAbsolute(t *testing.T) {
+	root := globTree(t)
+	got := Glob(filepath.Join(root, "*.txt"), "/nonexistent")
+	want := []string{filepath.Join(root, "c.txt")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Glob(absolute) = %q, want %q", got, want)
+	}
+}
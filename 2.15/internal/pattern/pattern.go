@@ -0,0 +1,135 @@
+// Package pattern реализует шаблоны шелла: *, ?, [...] и экранирование \.
+// В отличие от path.Match, * и ? совпадают с любыми символами, включая /
+package pattern
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Match сообщает, совпадает ли строка s с шаблоном целиком.
+// Некорректный класс символов (незакрытая [) сравнивается как обычный текст
+func Match(pattern, s string) bool {
+	// Классический алгоритм с возвратом к последней *
+	var starP, starS = -1, 0
+	p, i := 0, 0
+	for i < len(s) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				starP, starS = p, i
+				p++
+				continue
+			case '?':
+				_, w := utf8.DecodeRuneInString(s[i:])
+				p++
+				i += w
+				continue
+			case '[':
+				r, w := utf8.DecodeRuneInString(s[i:])
+				if ok, n, valid := matchClass(pattern[p:], r); valid {
+					if ok {
+						p += n
+						i += w
+						continue
+					}
+					break
+				}
+				if s[i] == '[' {
+					p++
+					i++
+					continue
+				}
+			case '\\':
+				if p+1 < len(pattern) && pattern[p+1] == s[i] {
+					p += 2
+					i++
+					continue
+				}
+			default:
+				if pattern[p] == s[i] {
+					p++
+					i++
+					continue
+				}
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		// * забирает ещё один символ
+		_, w := utf8.DecodeRuneInString(s[starS:])
+		starS += w
+		p, i = starP+1, starS
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchClass сравнивает r с классом [...] в начале pattern.
+// Возвращает результат, длину класса и признак корректности класса
+func matchClass(pattern string, r rune) (matched bool, n int, valid bool) {
+	i := 1
+	negate := false
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		negate = true
+		i++
+	}
+	first := true
+	for i < len(pattern) {
+		if pattern[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+		first = false
+
+		lo, w := classChar(pattern, i)
+		i += w
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, w = classChar(pattern, i+1)
+			i += 1 + w
+		}
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+	return false, 0, false
+}
+
+func classChar(pattern string, i int) (rune, int) {
+	if pattern[i] == '\\' && i+1 < len(pattern) {
+		r, w := utf8.DecodeRuneInString(pattern[i+1:])
+		return r, w + 1
+	}
+	return utf8.DecodeRuneInString(pattern[i:])
+}
+
+// HasMeta сообщает, содержит ли строка неэкранированные метасимволы шаблона
+func HasMeta(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '*', '?', '[':
+			return true
+		}
+	}
+	return false
+}
+
+// Escape экранирует метасимволы, чтобы строка совпадала только сама с собой
+func Escape(s string) string {
+	if !strings.ContainsAny(s, `*?[\`) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,108 @@
+package pattern
+
+import "testing"
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		// * и ?
+		{"*", "", true},
+		{"*", "abc", true},
+		{"*", "a/b", true},
+		{"*.go", "main.go", true},
+		{"*.go", "main.go.txt", false},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"**", "x", true},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"?", "é", true},
+		{"??", "é", false},
+		{"", "", true},
+		{"", "a", false},
+
+		// Классы символов
+		{"[abc]", "b", true},
+		{"[abc]", "d", false},
+		{"[a-c]x", "bx", true},
+		{"[a-cx-z]", "y", true},
+		{"[!a-c]", "d", true},
+		{"[!a-c]", "b", false},
+		{"[^a]", "b", true},
+		{"[^a]", "a", false},
+		{"[]]", "]", true},
+		{"[]a]", "a", true},
+		{"[]a]", "b", false},
+		{"[!]]", "]", false},
+		{"[!]]", "x", true},
+		{"[a-]", "-", true},
+		{"[\\]]", "]", true},
+		{"[а-я]", "ж", true},
+		{"[*]", "*", true},
+		{"[*]", "a", false},
+
+		// Незакрытая [ сравнивается как текст
+		{"[ab", "[ab", true},
+		{"[ab", "a", false},
+		{"[", "[", true},
+		{"a[", "a[", true},
+
+		// Экранирование
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{`a\?`, "a?", true},
+		{`\[a]`, "[a]", true},
+		{`\\`, `\`, true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestHasMeta(t *testing.T) {
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"plain", false},
+		{"a*", true},
+		{"a?", true},
+		{"[x", true},
+		{`a\*`, false},
+		{`a\\*`, true},
+		{"a]", false},
+	}
+	for _, tt := range tests {
+		if got := HasMeta(tt.s); got != tt.want {
+			t.Errorf("HasMeta(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"plain", "plain"},
+		{"a*b?[c]", `a\*b\?\[c]`},
+		{`back\slash`, `back\\slash`},
+	}
+	for _, tt := range tests {
+		got := Escape(tt.s)
+		if got != tt.want {
+			t.Errorf("Escape(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+		if !Match(got, tt.s) {
+			t.Errorf("Match(Escape(%q), %q) = false, want true", tt.s, tt.s)
+		}
+		if HasMeta(got) {
+			t.Errorf("HasMeta(Escape(%q)) = true, want false", tt.s)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Package minish встраивает интерпретатор mini-sh в программы на Go.
+// Скрипты выполняются в процессе программы без запуска mini-sh: встроенные
+// команды и подоболочки работают в горутинах, внешние команды запускаются
+// процессами. Текущий каталог, окружение и стандартные потоки процесса
+// интерпретатор не меняет, у него они свои
+package minish
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"wb-l2/internal/interp"
+)
+
+// Interpreter выполняет скрипты mini-sh. Состояние шелла — переменные,
+// функции, псевдонимы, опции и текущий каталог — сохраняется между вызовами
+// Run. Env, Dir, Name и Args читаются при первом вызове Run, потоки — при
+// каждом. Interpreter нельзя использовать из нескольких горутин одновременно
+type Interpreter struct {
+	// Stdin, Stdout и Stderr — потоки скрипта. Без Stdin скрипт читает
+	// пустой ввод, без Stdout и Stderr вывод отбрасывается
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	// Env — окружение NAME=value; nil — окружение процесса
+	Env []string
+	// Dir — начальный текущий каталог; пустой — каталог процесса
+	Dir string
+	// Name и Args — $0 и позиционные параметры, по умолчанию mini-sh без аргументов
+	Name string
+	Args []string
+
+	sh *interp.Shell
+}
+
+// Run выполняет скрипт и возвращает его код завершения, как mini-sh -c.
+// Синтаксические ошибки и ошибки команд выводятся в Stderr и отражаются
+// в коде. Отмена ctx прерывает скрипт, как SIGINT, и возвращается ошибка ctx.
+// Run не ждёт фоновых заданий: их вывод, записанный после завершения
+// скрипта, отбрасывается, пока задания не закроют потоки. Run возвращается,
+// когда остановлено копирование Stdin; заблокированное чтение Stdin
+// задерживает возврат
+func (i *Interpreter) Run(ctx context.Context, script string) (int, error) {
+	if i.sh == nil {
+		name := i.Name
+		if name == "" {
+			name = "mini-sh"
+		}
+		i.sh = interp.New(interp.Config{Name: name, Args: i.Args, Env: i.Env, Dir: i.Dir})
+	}
+
+	var st streams
+	defer st.close()
+	in, err := st.input(i.Stdin)
+	if err != nil {
+		return 1, err
+	}
+	out, err := st.output(i.Stdout)
+	if err != nil {
+		return 1, err
+	}
+	errOut, err := st.output(i.Stderr)
+	if err != nil {
+		return 1, err
+	}
+	i.sh.SetStreams(in, out, errOut)
+
+	status := i.sh.Run(ctx, script)
+	st.wait()
+	return status, ctx.Err()
+}
+
+// streams превращает потоки Interpreter в файлы, которые получают шелл
+// и внешние команды: файлы передаются как есть, остальные потоки
+// подключаются через каналы, данные по которым копируют горутины
+type streams struct {
+	// files — файлы, которые закрываются после Run; writers и readers —
+	// концы каналов вывода на стороне шелла и копирующих горутин
+	files   []*os.File
+	writers []*os.File
+	readers []*os.File
+	// copying завершается, когда горутины переписали вывод, записанный до конца Run
+	copying sync.WaitGroup
+	// reading завершается, когда горутина перестала читать Stdin
+	reading sync.WaitGroup
+	// mu упорядочивает запись, если Stdout и Stderr — один и тот же поток
+	mu sync.Mutex
+}
+
+func (st *streams) input(r io.Reader) (*os.File, error) {
+	switch r := r.(type) {
+	case nil:
+		return st.open(os.O_RDONLY)
+	case *os.File:
+		return r, nil
+	}
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	st.files = append(st.files, pr)
+	// горутина завершится, когда ввод кончится или после Run закроется
+	// канал; заблокированное чтение из r она не может прервать
+	st.reading.Add(1)
+	go func() {
+		defer st.reading.Done()
+		_, _ = io.Copy(pw, r)
+		pw.Close()
+	}()
+	return pr, nil
+}
+
+func (st *streams) output(w io.Writer) (*os.File, error) {
+	switch w := w.(type) {
+	case nil:
+		return st.open(os.O_WRONLY)
+	case *os.File:
+		return w, nil
+	}
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	st.writers = append(st.writers, pw)
+	st.readers = append(st.readers, pr)
+	st.copying.Add(1)
+	go func() {
+		defer pr.Close()
+		dst := &lockedWriter{mu: &st.mu, w: w}
+		_, err := io.Copy(dst, pr)
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			st.copying.Done()
+			return
+		}
+		// копии канала остались у фоновых заданий: уже записанное
+		// переписывается, а дальнейший вывод отбрасывается до их завершения
+		_ = pr.SetReadDeadline(time.Time{})
+		drain(pr, dst)
+		st.copying.Done()
+		_, _ = io.Copy(io.Discard, pr)
+	}()
+	return pw, nil
+}
+
+// drain переписывает в w данные, которые уже есть в канале r, не дожидаясь новых
+func drain(r *os.File, w io.Writer) {
+	rc, err := r.SyscallConn()
+	if err != nil {
+		return
+	}
+	buf := make([]byte, 32*1024)
+	for {
+		var n int
+		var rerr error
+		// функция возвращает true, поэтому пустой неблокирующий канал
+		// даёт EAGAIN, а не ожидание
+		err := rc.Read(func(fd uintptr) bool {
+			n, rerr = syscall.Read(int(fd), buf)
+			return true
+		})
+		if err != nil || rerr != nil || n <= 0 {
+			return
+		}
+		if _, err := w.Write(buf[:n]); err != nil {
+			return
+		}
+	}
+}
+
+// open открывает /dev/null для отсутствующего потока
+func (st *streams) open(flag int) (*os.File, error) {
+	f, err := os.OpenFile(os.DevNull, flag, 0)
+	if err != nil {
+		return nil, err
+	}
+	st.files = append(st.files, f)
+	return f, nil
+}
+
+// wait закрывает концы каналов вывода на стороне шелла и ждёт, пока
+// горутины перепишут оставшиеся данные. Вывод шелла и заданий переднего
+// плана к этому моменту уже в каналах, поэтому срок чтения прерывает только
+// ожидание копий, оставшихся у фоновых заданий
+func (st *streams) wait() {
+	for _, f := range st.writers {
+		f.Close()
+	}
+	for _, f := range st.readers {
+		_ = f.SetReadDeadline(time.Now())
+	}
+	st.writers, st.readers = nil, nil
+	st.copying.Wait()
+}
+
+func (st *streams) close() {
+	st.wait()
+	for _, f := range st.files {
+		f.Close()
+	}
+	st.reading.Wait()
+}
+
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,149 @@
+package minish
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunOutput(t *testing.T) {
+	var out, errOut bytes.Buffer
+	i := &Interpreter{Stdout: &out, Stderr: &errOut, Name: "test", Args: []string{"a", "b c"}}
+	status, err := i.Run(context.Background(), `echo "$0 $# $2"; echo oops >&2; ls /nonexistent-dir | cat`)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if status != 0 {
+		t.Errorf("Run() = %d, want 0", status)
+	}
+	if got, want := out.String(), "test 2 b c\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got := errOut.String(); !strings.HasPrefix(got, "oops\n") || !strings.Contains(got, "nonexistent-dir") {
+		t.Errorf("stderr = %q, want oops and the ls error", got)
+	}
+
+	out.Reset()
+	errOut.Reset()
+	status, _ = i.Run(context.Background(), "echo 'unterminated")
+	if status != 2 || out.Len() != 0 || !strings.Contains(errOut.String(), "mini-sh:") {
+		t.Errorf("syntax error: status = %d, stdout = %q, stderr = %q", status, out.String(), errOut.String())
+	}
+}
+
+func TestRunStdin(t *testing.T) {
+	var out bytes.Buffer
+	i := &Interpreter{Stdin: strings.NewReader("one\ntwo\n"), Stdout: &out}
+	if _, err := i.Run(context.Background(), "read a; echo got $a; cat"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got, want := out.String(), "got one\ntwo\n"; got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+}
+
+func TestRunIsolation(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	i := &Interpreter{Stdout: &out, Dir: dir, Env: []string{"PATH=" + os.Getenv("PATH"), "ONLY=1"}}
+	script := "pwd; /bin/pwd; echo $ONLY $HOME; export NEW=2; env | sort; mkdir sub; cd sub"
+	if _, err := i.Run(context.Background(), script); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	want := dir + "\n" + dir + "\n1\nNEW=2\nONLY=1\nPATH=" + os.Getenv("PATH") + "\nPWD=" + dir + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("stdout = %q, want %q", got, want)
+	}
+	if got, _ := os.Getwd(); got != wd {
+		t.Errorf("process cwd = %q, want %q", got, wd)
+	}
+	if v, ok := os.LookupEnv("NEW"); ok {
+		t.Errorf("process env NEW = %q, want unset", v)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub")); err != nil {
+		t.Errorf("mkdir sub in Dir: %v", err)
+	}
+}
+
+func TestRunKeepsState(t *testing.T) {
+	var out bytes.Buffer
+	i := &Interpreter{Stdout: &out, Dir: t.TempDir()}
+	steps := []struct {
+		script string
+		want   string
+	}{
+		{"x=1; f() { echo f$x; }; alias hi='echo hi'; set -o pipefail; mkdir d; cd d; false", ""},
+		{"echo $? $x; f; hi; pwd | sed 's|.*/||'", "1 1\nf1\nhi\nd\n"},
+		{"false | true; echo $?; unset x; f", "1\nf\n"},
+	}
+	for _, step := range steps {
+		out.Reset()
+		if _, err := i.Run(context.Background(), step.script); err != nil {
+			t.Fatalf("Run(%q) error = %v", step.script, err)
+		}
+		if got := out.String(); got != step.want {
+			t.Errorf("Run(%q) stdout = %q, want %q", step.script, got, step.want)
+		}
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	var out bytes.Buffer
+	i := &Interpreter{Stdout: &out}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	status, err := i.Run(ctx, "echo before; sleep 5; echo after")
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run() took %v after cancellation", elapsed)
+	}
+	if status != 130 {
+		t.Errorf("Run() = %d, want 130", status)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := out.String(); got != "before\n" {
+		t.Errorf("stdout = %q, want %q", got, "before\n")
+	}
+
+	// после отмены интерпретатор остаётся пригодным
+	out.Reset()
+	if status, err := i.Run(context.Background(), "echo again"); status != 0 || err != nil || out.String() != "again\n" {
+		t.Errorf("Run() after cancel = %d, %v, stdout %q", status, err, out.String())
+	}
+}
+
+func TestRunBackground(t *testing.T) {
+	var out bytes.Buffer
+	i := &Interpreter{Stdout: &out}
+	start := time.Now()
+	status, err := i.Run(context.Background(), "echo bg; { sleep 1; echo late; } & sleep 2 & echo fg")
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Run() waited %v for background jobs", elapsed)
+	}
+	if status != 0 || err != nil {
+		t.Errorf("Run() = %d, %v, want 0, nil", status, err)
+	}
+	if got := out.String(); got != "bg\nfg\n" {
+		t.Errorf("stdout = %q, want %q", got, "bg\nfg\n")
+	}
+
+	// задания остаются в таблице интерпретатора, а их поздний вывод не попадает в Stdout
+	out.Reset()
+	if _, err := i.Run(context.Background(), "wait; echo done"); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := out.String(); got != "done\n" {
+		t.Errorf("stdout = %q, want %q", got, "done\n")
+	}
+}
@@ -0,0 +1,274 @@
+// Command loadgen drives the calendar HTTP server with a configurable mix
+// of create/update/query requests and reports throughput and latency percentiles.
+//
+//	go run ./cmd/loadgen -addr http://localhost:8080 -duration 30s -workers 32 \
+//		-mix create=20,update=10,day=30,week=20,month=20
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var operations = []string{"create", "update", "day", "week", "month"}
+
+type config struct {
+	addr     string
+	duration time.Duration
+	workers  int
+	users    int
+	prefill  int
+	mix      map[string]int
+	insecure bool
+	seed     int64
+}
+
+// generator issues requests and remembers created event IDs for updates
+type generator struct {
+	cfg    config
+	client *http.Client
+	base   time.Time
+
+	mu  sync.Mutex
+	ids []int
+}
+
+func main() {
+	var cfg config
+	var mix string
+
+	flag.StringVar(&cfg.addr, "addr", "http://localhost:8080", "server base URL")
+	flag.DurationVar(&cfg.duration, "duration", 10*time.Second, "test duration")
+	flag.IntVar(&cfg.workers, "workers", 16, "number of concurrent workers")
+	flag.IntVar(&cfg.users, "users", 100, "number of distinct users")
+	flag.IntVar(&cfg.prefill, "prefill", 0, "events to create per user before the test")
+	flag.StringVar(&mix, "mix", "create=20,update=10,day=30,week=20,month=20", "operation weights")
+	flag.BoolVar(&cfg.insecure, "insecure", false, "skip TLS certificate verification")
+	flag.Int64Var(&cfg.seed, "seed", time.Now().UnixNano(), "random seed")
+	flag.Parse()
+
+	var err error
+	cfg.mix, err = parseMix(mix)
+	if err != nil {
+		log.Fatalf("Invalid -mix: %v", err)
+	}
+
+	g := &generator{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				MaxIdleConnsPerHost: cfg.workers,
+				TLSClientConfig:     &tls.Config{InsecureSkipVerify: cfg.insecure},
+			},
+		},
+		base: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	if cfg.prefill > 0 {
+		log.Printf("Prefilling %d events for %d users", cfg.prefill, cfg.users)
+		if err := g.prefill(); err != nil {
+			log.Fatalf("Prefill failed: %v", err)
+		}
+	}
+
+	all := make(map[string]*stats)
+	for _, op := range operations {
+		all[op] = &stats{}
+	}
+
+	log.Printf("Running %d workers for %v against %s", cfg.workers, cfg.duration, cfg.addr)
+
+	deadline := time.Now().Add(cfg.duration)
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(cfg.seed + int64(worker)))
+			for time.Now().Before(deadline) {
+				op := pick(rnd, cfg.mix)
+				began := time.Now()
+				err := g.do(rnd, op)
+				all[op].record(time.Since(began), err)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	report(os.Stdout, operations, all, time.Since(start))
+}
+
+// parseMix parses weights in the form "create=20,update=10"
+func parseMix(s string) (map[string]int, error) {
+	mix := make(map[string]int)
+	total := 0
+
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("expected op=weight, got %q", part)
+		}
+
+		known := false
+		for _, op := range operations {
+			known = known || op == kv[0]
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown operation %q", kv[0])
+		}
+
+		weight, err := strconv.Atoi(kv[1])
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q", kv[1])
+		}
+
+		mix[kv[0]] = weight
+		total += weight
+	}
+
+	if total == 0 {
+		return nil, errors.New("total weight must be positive")
+	}
+
+	return mix, nil
+}
+
+func pick(rnd *rand.Rand, mix map[string]int) string {
+	total := 0
+	for _, op := range operations {
+		total += mix[op]
+	}
+
+	n := rnd.Intn(total)
+	for _, op := range operations {
+		if n < mix[op] {
+			return op
+		}
+		n -= mix[op]
+	}
+
+	return operations[0]
+}
+
+func (g *generator) prefill() error {
+	rnd := rand.New(rand.NewSource(g.cfg.seed))
+	for user := 1; user <= g.cfg.users; user++ {
+		for i := 0; i < g.cfg.prefill; i++ {
+			if err := g.create(user, g.randomDate(rnd)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (g *generator) do(rnd *rand.Rand, op string) error {
+	user := 1 + rnd.Intn(g.cfg.users)
+	date := g.randomDate(rnd)
+
+	switch op {
+	case "create":
+		return g.create(user, date)
+	case "update":
+		id, ok := g.randomID(rnd)
+		if !ok {
+			return g.create(user, date)
+		}
+		return g.post("/update_event", url.Values{
+			"id":      {strconv.Itoa(id)},
+			"user_id": {strconv.Itoa(user)},
+			"date":    {date},
+			"event":   {"loadgen update"},
+		}, nil)
+	default:
+		return g.get("/events_for_"+op, url.Values{
+			"user_id": {strconv.Itoa(user)},
+			"date":    {date},
+		})
+	}
+}
+
+func (g *generator) create(user int, date string) error {
+	var resp struct {
+		Result struct {
+			ID int `json:"id"`
+		} `json:"result"`
+	}
+
+	err := g.post("/create_event", url.Values{
+		"user_id": {strconv.Itoa(user)},
+		"date":    {date},
+		"event":   {"loadgen event"},
+	}, &resp)
+	if err != nil {
+		return err
+	}
+
+	g.mu.Lock()
+	g.ids = append(g.ids, resp.Result.ID)
+	g.mu.Unlock()
+
+	return nil
+}
+
+func (g *generator) randomID(rnd *rand.Rand) (int, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.ids) == 0 {
+		return 0, false
+	}
+	return g.ids[rnd.Intn(len(g.ids))], true
+}
+
+func (g *generator) randomDate(rnd *rand.Rand) string {
+	return g.base.AddDate(0, 0, rnd.Intn(365)).Format("2006-01-02")
+}
+
+func (g *generator) post(path string, form url.Values, result interface{}) error {
+	resp, err := g.client.PostForm(g.cfg.addr+path, form)
+	if err != nil {
+		return err
+	}
+	return readResponse(resp, result)
+}
+
+func (g *generator) get(path string, query url.Values) error {
+	resp, err := g.client.Get(g.cfg.addr + path + "?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	return readResponse(resp, nil)
+}
+
+func readResponse(resp *http.Response, result interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	if result == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// stats collects latencies of a single operation type
+type stats struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    int
+}
+
+func (s *stats) record(d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.errors++
+		return
+	}
+	s.latencies = append(s.latencies, d)
+}
+
+// percentile returns the p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p / 100)
+	return sorted[i]
+}
+
+// report prints throughput and latency percentiles per operation
+func report(w io.Writer, ops []string, all map[string]*stats, elapsed time.Duration) {
+	fmt.Fprintf(w, "%-8s %8s %6s %10s %10s %10s %10s %10s\n",
+		"op", "ok", "errors", "rps", "p50", "p90", "p99", "max")
+
+	var total, totalErrors int
+	var merged []time.Duration
+
+	for _, op := range ops {
+		s := all[op]
+		s.mu.Lock()
+		sorted := append([]time.Duration(nil), s.latencies...)
+		errors := s.errors
+		s.mu.Unlock()
+
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		printRow(w, op, sorted, errors, elapsed)
+
+		total += len(sorted)
+		totalErrors += errors
+		merged = append(merged, sorted...)
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i] < merged[j] })
+	printRow(w, "total", merged, totalErrors, elapsed)
+}
+
+func printRow(w io.Writer, op string, sorted []time.Duration, errors int, elapsed time.Duration) {
+	var max time.Duration
+	if len(sorted) > 0 {
+		max = sorted[len(sorted)-1]
+	}
+
+	fmt.Fprintf(w, "%-8s %8d %6d %10.1f %10v %10v %10v %10v\n",
+		op,
+		len(sorted),
+		errors,
+		float64(len(sorted))/elapsed.Seconds(),
+		percentile(sorted, 50).Round(time.Microsecond),
+		percentile(sorted, 90).Round(time.Microsecond),
+		percentile(sorted, 99).Round(time.Microsecond),
+		max.Round(time.Microsecond),
+	)
+}
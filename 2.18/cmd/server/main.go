@@ -2,20 +2,62 @@ package main
 
 import (
 	"calendar/internal/config"
+	"calendar/internal/digest"
 	"calendar/internal/handler"
+	"calendar/internal/holiday"
 	"calendar/internal/middleware"
 	"calendar/internal/service"
+	"crypto/tls"
 	"log"
 	"net/http"
+	"time"
+	_ "time/tzdata"
 )
 
 func main() {
 	cfg := config.Load()
 
+	if err := cfg.ValidateTLS(); err != nil {
+		log.Fatalf("Invalid TLS settings: %v", err)
+	}
+
 	eventService := service.NewEventService()
 
+	if cfg.WeekStart != "" {
+		weekStart, err := service.ParseWeekday(cfg.WeekStart)
+		if err != nil {
+			log.Fatalf("Invalid WEEK_START: %v", err)
+		}
+		eventService.SetWeekStart(weekStart)
+	}
+
+	if cfg.HolidaysFile != "" {
+		holidays, err := holiday.Load(cfg.HolidaysFile)
+		if err != nil {
+			log.Fatalf("Failed to load holiday calendar: %v", err)
+		}
+		eventService.SetHolidayCalendar(holidays, cfg.HolidaysAsEvents)
+	}
+
 	eventHandler := handler.NewEventHandler(eventService)
 
+	location := time.UTC
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			log.Fatalf("Invalid TIMEZONE: %v", err)
+		}
+		location = loc
+		eventHandler.SetLocation(loc)
+	}
+
+	digestBuilder := digest.NewBuilder(eventService)
+	digestHandler := handler.NewDigestHandler(digestBuilder)
+
+	if cfg.DigestSchedule != "" {
+		startDigestScheduler(cfg, digestBuilder, eventService, location)
+	}
+
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/create_event", eventHandler.CreateEvent)
@@ -24,12 +66,79 @@ func main() {
 	mux.HandleFunc("/events_for_day", eventHandler.GetEventsForDay)
 	mux.HandleFunc("/events_for_week", eventHandler.GetEventsForWeek)
 	mux.HandleFunc("/events_for_month", eventHandler.GetEventsForMonth)
+	mux.HandleFunc("/events_for_iso_week", eventHandler.GetEventsForISOWeek)
+	mux.HandleFunc("/holidays", eventHandler.GetHolidays)
+	mux.HandleFunc("/agenda_digest", digestHandler.GetDigest)
+
+	corsMux := middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins: cfg.CORSAllowedOrigins,
+		AllowedMethods: cfg.CORSAllowedMethods,
+		AllowedHeaders: cfg.CORSAllowedHeaders,
+	})(mux)
 
-	loggedMux := middleware.Logger(mux)
+	loggedMux := middleware.Logger(corsMux)
+
+	server := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: loggedMux,
+	}
+
+	if !cfg.TLSEnabled() {
+		log.Printf("Starting server on %s", server.Addr)
+		if err := server.ListenAndServe(); err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+		return
+	}
 
-	address := ":" + cfg.Port
-	log.Printf("Starting server on %s", address)
-	if err := http.ListenAndServe(address, loggedMux); err != nil {
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		log.Printf("Starting TLS server on %s", server.Addr)
+		if err := server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile); err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+		return
+	}
+
+	cert, err := selfSignedCertificate()
+	if err != nil {
+		log.Fatalf("Failed to generate self-signed certificate: %v", err)
+	}
+	server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	log.Printf("Starting TLS server on %s with self-signed certificate (development only)", server.Addr)
+	if err := server.ListenAndServeTLS("", ""); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+func startDigestScheduler(cfg *config.Config, builder *digest.Builder, eventService *service.EventService, loc *time.Location) {
+	schedule, err := digest.ParseSchedule(cfg.DigestSchedule)
+	if err != nil {
+		log.Fatalf("Invalid DIGEST_SCHEDULE: %v", err)
+	}
+
+	period := digest.Period(cfg.DigestPeriod)
+	if period != digest.PeriodDay && period != digest.PeriodWeek {
+		log.Fatalf("Invalid DIGEST_PERIOD: %q", cfg.DigestPeriod)
+	}
+
+	format := digest.Format(cfg.DigestFormat)
+	if format != digest.FormatText && format != digest.FormatMarkdown && format != digest.FormatHTML {
+		log.Fatalf("Invalid DIGEST_FORMAT: %q", cfg.DigestFormat)
+	}
+
+	var sender digest.Sender
+	switch cfg.DigestSender {
+	case "file":
+		sender = digest.NewFileSender(cfg.DigestDir)
+	case "smtp":
+		sender = digest.NewSMTPSender(cfg.DigestSMTPAddr, cfg.DigestSMTPFrom, cfg.DigestSMTPDomain)
+	default:
+		log.Fatalf("Invalid DIGEST_SENDER: %q", cfg.DigestSender)
+	}
+
+	scheduler := digest.NewScheduler(builder, sender, schedule, period, format, loc, eventService.UserIDs)
+	go scheduler.Run(make(chan struct{}))
+
+	log.Printf("Digest scheduler started: %q, %s digest via %s", cfg.DigestSchedule, period, cfg.DigestSender)
+}
@@ -1,13 +1,46 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 )
 
 // Config contains application configuration
 type Config struct {
 	Port string
+
+	// CORS settings
+	CORSAllowedOrigins []string
+	CORSAllowedMethods []string
+	CORSAllowedHeaders []string
+
+	// TLS settings
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSSelfSigned bool
+
+	// WeekStart is the first day of the week for /events_for_week
+	// (e.g. "monday"). Empty means 7 days from the requested date
+	WeekStart string
+
+	// Timezone is the default IANA timezone used to resolve relative dates
+	Timezone string
+
+	// Holiday calendar settings
+	HolidaysFile     string
+	HolidaysAsEvents bool
+
+	// Digest settings. Scheduled digests are disabled when DigestSchedule is empty
+	DigestSchedule   string
+	DigestPeriod     string
+	DigestFormat     string
+	DigestSender     string
+	DigestDir        string
+	DigestSMTPAddr   string
+	DigestSMTPFrom   string
+	DigestSMTPDomain string
 }
 
 // Load loads configuration from environment variables
@@ -21,7 +54,74 @@ func Load() *Config {
 		port = "8080"
 	}
 
+	selfSigned, _ := strconv.ParseBool(os.Getenv("TLS_SELF_SIGNED"))
+	holidaysAsEvents, _ := strconv.ParseBool(os.Getenv("HOLIDAYS_AS_EVENTS"))
+
 	return &Config{
 		Port: port,
+
+		CORSAllowedOrigins: getList("CORS_ALLOWED_ORIGINS", nil),
+		CORSAllowedMethods: getList("CORS_ALLOWED_METHODS", []string{"GET", "POST", "OPTIONS"}),
+		CORSAllowedHeaders: getList("CORS_ALLOWED_HEADERS", []string{"Content-Type"}),
+
+		TLSCertFile:   os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:    os.Getenv("TLS_KEY_FILE"),
+		TLSSelfSigned: selfSigned,
+
+		WeekStart: os.Getenv("WEEK_START"),
+		Timezone:  os.Getenv("TIMEZONE"),
+
+		HolidaysFile:     os.Getenv("HOLIDAYS_FILE"),
+		HolidaysAsEvents: holidaysAsEvents,
+
+		DigestSchedule:   os.Getenv("DIGEST_SCHEDULE"),
+		DigestPeriod:     getEnv("DIGEST_PERIOD", "day"),
+		DigestFormat:     getEnv("DIGEST_FORMAT", "text"),
+		DigestSender:     getEnv("DIGEST_SENDER", "file"),
+		DigestDir:        getEnv("DIGEST_DIR", "digests"),
+		DigestSMTPAddr:   getEnv("DIGEST_SMTP_ADDR", "localhost:1025"),
+		DigestSMTPFrom:   getEnv("DIGEST_SMTP_FROM", "calendar@localhost"),
+		DigestSMTPDomain: getEnv("DIGEST_SMTP_DOMAIN", "localhost"),
+	}
+}
+
+// TLSEnabled reports whether the server should be served over HTTPS.
+// Setting only one of the certificate and key files still enables TLS,
+// so that ValidateTLS can reject it instead of falling back to HTTP
+func (c *Config) TLSEnabled() bool {
+	return c.TLSSelfSigned || c.TLSCertFile != "" || c.TLSKeyFile != ""
+}
+
+// ValidateTLS checks that the certificate and key files are set together
+func (c *Config) ValidateTLS() error {
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	return nil
+}
+
+// getEnv reads the environment variable or returns def if it is empty
+func getEnv(key, def string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
 	}
+	return def
+}
+
+// getList reads a comma-separated list from the environment variable
+func getList(key string, def []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return def
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			result = append(result, item)
+		}
+	}
+
+	return result
 }
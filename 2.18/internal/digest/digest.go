@@ -0,0 +1,234 @@
+package digest
+
+import (
+	"bytes"
+	"calendar/internal/model"
+	"calendar/internal/service"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// Period is the time span covered by a digest
+type Period string
+
+const (
+	// PeriodDay is a digest for a single day
+	PeriodDay Period = "day"
+	// PeriodWeek is a digest for the week containing the date
+	PeriodWeek Period = "week"
+)
+
+// Format is the output format of a rendered digest
+type Format string
+
+const (
+	// FormatText is plain text
+	FormatText Format = "text"
+	// FormatMarkdown is Markdown
+	FormatMarkdown Format = "markdown"
+	// FormatHTML is an HTML document
+	FormatHTML Format = "html"
+)
+
+var (
+	// ErrInvalidPeriod is returned when period is not "day" or "week"
+	ErrInvalidPeriod = errors.New("invalid period, expected day or week")
+	// ErrInvalidFormat is returned when format is not text, markdown or html
+	ErrInvalidFormat = errors.New("invalid format, expected text, markdown or html")
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var (
+	textTemplates = template.Must(template.New("").Funcs(funcs).ParseFS(templateFS, "templates/*.txt.tmpl", "templates/*.md.tmpl"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.New("").Funcs(funcs).ParseFS(templateFS, "templates/*.html.tmpl"))
+)
+
+var funcs = map[string]interface{}{
+	"date": func(t time.Time) string { return t.Format("02.01.2006") },
+	"weekday": func(t time.Time) string {
+		return t.Weekday().String()
+	},
+	"clock": func(t time.Time) string {
+		if t.Hour() == 0 && t.Minute() == 0 {
+			return ""
+		}
+		return t.Format("15:04")
+	},
+}
+
+// Day is a group of events on one calendar day
+type Day struct {
+	Date   time.Time
+	Events []*model.Event
+}
+
+// Digest is an agenda of a user for a day or a week
+type Digest struct {
+	UserID int
+	Period Period
+	// From and To are the first and the last day covered, inclusive
+	From time.Time
+	To   time.Time
+	Days []Day
+}
+
+// Message is a rendered digest ready to be delivered
+type Message struct {
+	UserID  int
+	Subject string
+	Format  Format
+	Body    string
+}
+
+// Builder builds digests from the event service
+type Builder struct {
+	service *service.EventService
+}
+
+// NewBuilder creates a new digest builder
+func NewBuilder(service *service.EventService) *Builder {
+	return &Builder{
+		service: service,
+	}
+}
+
+// Build collects the agenda of the user for the period containing dateStr
+func (b *Builder) Build(userID int, period Period, dateStr string) (*Digest, error) {
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return nil, service.ErrInvalidDate
+	}
+
+	var (
+		events   []*model.Event
+		from, to time.Time
+	)
+
+	switch period {
+	case PeriodDay:
+		events, err = b.service.GetEventsForDay(userID, dateStr)
+		from, to = date, date
+	case PeriodWeek:
+		events, err = b.service.GetEventsForWeek(userID, dateStr)
+		start, end := b.service.WeekRange(date)
+		from, to = start, end.AddDate(0, 0, -1)
+	default:
+		return nil, ErrInvalidPeriod
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Digest{
+		UserID: userID,
+		Period: period,
+		From:   from,
+		To:     to,
+		Days:   groupByDay(events),
+	}, nil
+}
+
+// Subject returns a short title of the digest
+func (d *Digest) Subject() string {
+	if d.Period == PeriodDay {
+		return "Agenda for " + d.From.Format("02.01.2006")
+	}
+	return "Agenda for " + d.From.Format("02.01.2006") + " - " + d.To.Format("02.01.2006")
+}
+
+// Render renders the digest in the given format
+func (d *Digest) Render(format Format) (string, error) {
+	var buf bytes.Buffer
+	var err error
+
+	switch format {
+	case FormatText:
+		err = textTemplates.ExecuteTemplate(&buf, "digest.txt.tmpl", d)
+	case FormatMarkdown:
+		err = textTemplates.ExecuteTemplate(&buf, "digest.md.tmpl", d)
+	case FormatHTML:
+		err = htmlTemplates.ExecuteTemplate(&buf, "digest.html.tmpl", d)
+	default:
+		return "", ErrInvalidFormat
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Message renders the digest into a message for delivery
+func (d *Digest) Message(format Format) (*Message, error) {
+	body, err := d.Render(format)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		UserID:  d.UserID,
+		Subject: d.Subject(),
+		Format:  format,
+		Body:    body,
+	}, nil
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	switch f {
+	case FormatMarkdown:
+		return ".md"
+	case FormatHTML:
+		return ".html"
+	default:
+		return ".txt"
+	}
+}
+
+func groupByDay(events []*model.Event) []Day {
+	sorted := make([]*model.Event, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return localTime(sorted[i].Date).Before(localTime(sorted[j].Date))
+	})
+
+	var days []Day
+	for _, event := range sorted {
+		y, m, d := event.Date.Date()
+		if len(days) == 0 || !sameDate(days[len(days)-1].Date, y, m, d) {
+			days = append(days, Day{Date: time.Date(y, m, d, 0, 0, 0, 0, event.Date.Location())})
+		}
+		days[len(days)-1].Events = append(days[len(days)-1].Events, event)
+	}
+
+	return days
+}
+
+// localTime drops the timezone keeping the local date and time, so events
+// created in different timezones are ordered by the user's own calendar
+func localTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func sameDate(t time.Time, year int, month time.Month, day int) bool {
+	y, m, d := t.Date()
+	return y == year && m == month && d == day
+}
@@ -0,0 +1,171 @@
+package digest
+
+import (
+	"calendar/internal/service"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	svc := service.NewEventService()
+	svc.CreateEvent(1, "2024-01-16", "Retro")
+	svc.CreateEvent(1, "2024-01-15", "Planning")
+	svc.CreateEvent(1, "2024-01-15", "Standup")
+	svc.CreateEvent(1, "2024-01-25", "Next week")
+
+	builder := NewBuilder(svc)
+
+	d, err := builder.Build(1, PeriodWeek, "2024-01-15")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if len(d.Days) != 2 || len(d.Days[0].Events) != 2 || d.Days[1].Events[0].EventText != "Retro" {
+		t.Errorf("Build() days = %+v", d.Days)
+	}
+	if d.To.Format("2006-01-02") != "2024-01-21" {
+		t.Errorf("Build() To = %v, want 2024-01-21", d.To)
+	}
+
+	if _, err := builder.Build(1, "year", "2024-01-15"); err != ErrInvalidPeriod {
+		t.Errorf("Build() error = %v, want %v", err, ErrInvalidPeriod)
+	}
+	if _, err := builder.Build(1, PeriodDay, "15.01.2024"); err != service.ErrInvalidDate {
+		t.Errorf("Build() error = %v, want %v", err, service.ErrInvalidDate)
+	}
+}
+
+func TestBuilder_BuildTimezones(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	svc := service.NewEventService()
+	svc.CreateEventAt(1, time.Date(2024, 1, 16, 8, 0, 0, 0, tokyo), "Tokyo call")
+	svc.CreateEventAt(1, time.Date(2024, 1, 16, 7, 0, 0, 0, time.UTC), "Standup")
+	svc.CreateEventAt(1, time.Date(2024, 1, 15, 23, 30, 0, 0, time.UTC), "Late review")
+
+	d, err := NewBuilder(svc).Build(1, PeriodWeek, "2024-01-15")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if len(d.Days) != 2 {
+		t.Fatalf("Build() days = %+v, want 2", d.Days)
+	}
+	if got := d.Days[0].Events; len(got) != 1 || got[0].EventText != "Late review" {
+		t.Errorf("Build() first day = %+v", got)
+	}
+	if got := d.Days[1].Events; len(got) != 2 || got[0].EventText != "Standup" || got[1].EventText != "Tokyo call" {
+		t.Errorf("Build() second day = %+v", got)
+	}
+}
+
+func TestDigest_Render(t *testing.T) {
+	svc := service.NewEventService()
+	svc.CreateEvent(1, "2024-01-15", "Planning <team>")
+
+	d, err := NewBuilder(svc).Build(1, PeriodDay, "2024-01-15")
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	tests := []struct {
+		format Format
+		want   []string
+	}{
+		{FormatText, []string{"Agenda for 15.01.2024", "  - Planning <team>"}},
+		{FormatMarkdown, []string{"# Agenda for 15.01.2024", "## 15.01.2024 (Monday)", "- Planning <team>"}},
+		{FormatHTML, []string{"<h1>Agenda for 15.01.2024</h1>", "<li>Planning &lt;team&gt;</li>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			body, err := d.Render(tt.format)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("Render() = %q, want to contain %q", body, want)
+				}
+			}
+		})
+	}
+
+	if _, err := d.Render("pdf"); err != ErrInvalidFormat {
+		t.Errorf("Render() error = %v, want %v", err, ErrInvalidFormat)
+	}
+
+	empty, _ := NewBuilder(svc).Build(2, PeriodDay, "2024-01-15")
+	if body, _ := empty.Render(FormatText); !strings.Contains(body, "No events.") {
+		t.Errorf("Render() of empty digest = %q", body)
+	}
+}
+
+func TestSchedule_Matches(t *testing.T) {
+	// Monday, 15 January 2024
+	monday := time.Date(2024, 1, 15, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		time time.Time
+		want bool
+	}{
+		{"0 8 * * *", monday, true},
+		{"0 8 * * *", monday.Add(time.Minute), false},
+		{"*/15 * * * *", monday.Add(45 * time.Minute), true},
+		{"*/15 * * * *", monday.Add(50 * time.Minute), false},
+		{"0 8 * * 1-5", monday, true},
+		{"0 8 * * 6,7", monday, false},
+		{"0 8 * * 0", monday.AddDate(0, 0, 6), true},
+		{"0 8 * * 7", monday.AddDate(0, 0, 6), true},
+		{"0 8 1 * 1", monday, true},
+		{"0 8 1 * 2", monday, false},
+		{"@daily", monday.Add(-8 * time.Hour), true},
+		{"@weekly", monday.Add(-8 * time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseSchedule() error = %v", err)
+			}
+			if got := schedule.Matches(tt.time); got != tt.want {
+				t.Errorf("Matches(%v) = %v, want %v", tt.time, got, tt.want)
+			}
+		})
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) expected error", spec)
+		}
+	}
+}
+
+func TestScheduler_RunOnce(t *testing.T) {
+	svc := service.NewEventService()
+	svc.CreateEvent(1, "2024-01-15", "Planning")
+	svc.CreateEvent(2, "2024-01-15", "Review")
+
+	dir := t.TempDir()
+	sender := NewFileSender(dir)
+	schedule, _ := ParseSchedule("@daily")
+
+	scheduler := NewScheduler(NewBuilder(svc), sender, schedule, PeriodDay, FormatMarkdown, time.UTC, svc.UserIDs)
+	scheduler.RunOnce(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC))
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	if len(files) != 2 {
+		t.Fatalf("RunOnce() wrote %d files, want 2", len(files))
+	}
+
+	data, _ := os.ReadFile(files[0])
+	if !strings.Contains(string(data), "Planning") {
+		t.Errorf("digest file = %q, want to contain Planning", data)
+	}
+}
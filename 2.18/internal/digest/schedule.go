@@ -0,0 +1,203 @@
+package digest
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron-like schedule with five fields:
+// minute, hour, day of month, month and day of week.
+// Each field accepts "*", numbers, ranges "a-b", lists "a,b" and steps "*/n"
+type Schedule struct {
+	minute, hour, dom, month, dow fieldSet
+	// domAny and dowAny follow cron rules: when both day fields
+	// are restricted, either of them matching is enough
+	domAny, dowAny bool
+}
+
+type fieldSet map[int]bool
+
+var shortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 1",
+	"@monthly": "0 0 1 * *",
+}
+
+// ParseSchedule parses a cron expression such as "0 8 * * 1-5" or "@daily"
+func ParseSchedule(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if expanded, ok := shortcuts[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q: expected 5 fields", spec)
+	}
+
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := make([]fieldSet, 5)
+	for i, field := range fields {
+		set, err := parseField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday can be written both as 0 and 7
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	return &Schedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// Matches reports whether the schedule fires at the minute of t
+func (s *Schedule) Matches(t time.Time) bool {
+	if !s.minute[t.Minute()] || !s.hour[t.Hour()] || !s.month[int(t.Month())] {
+		return false
+	}
+
+	domMatch := s.dom[t.Day()]
+	dowMatch := s.dow[int(t.Weekday())]
+
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dowMatch
+	case s.dowAny:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+func parseField(field string, min, max int) (fieldSet, error) {
+	set := make(fieldSet)
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("value out of range in %q", part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
+
+// Scheduler periodically builds digests for all users and delivers them
+type Scheduler struct {
+	builder  *Builder
+	sender   Sender
+	schedule *Schedule
+	period   Period
+	format   Format
+	location *time.Location
+	users    func() []int
+}
+
+// NewScheduler creates a scheduler that sends digests of the given period and format
+// to every user returned by users whenever the schedule fires
+func NewScheduler(builder *Builder, sender Sender, schedule *Schedule, period Period, format Format, loc *time.Location, users func() []int) *Scheduler {
+	return &Scheduler{
+		builder:  builder,
+		sender:   sender,
+		schedule: schedule,
+		period:   period,
+		format:   format,
+		location: loc,
+		users:    users,
+	}
+}
+
+// Run checks the schedule once a minute until stop is closed
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	for {
+		now := time.Now().In(s.location)
+		next := now.Truncate(time.Minute).Add(time.Minute)
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if s.schedule.Matches(next) {
+			s.RunOnce(next)
+		}
+	}
+}
+
+// RunOnce builds and sends digests for the date of t
+func (s *Scheduler) RunOnce(t time.Time) {
+	date := t.Format("2006-01-02")
+
+	for _, userID := range s.users() {
+		d, err := s.builder.Build(userID, s.period, date)
+		if err != nil {
+			log.Printf("Digest for user %d: %v", userID, err)
+			continue
+		}
+
+		msg, err := d.Message(s.format)
+		if err != nil {
+			log.Printf("Digest for user %d: %v", userID, err)
+			continue
+		}
+
+		if err := s.sender.Send(msg); err != nil {
+			log.Printf("Digest for user %d: send failed: %v", userID, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package digest
+
+import (
+	"fmt"
+	"mime"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sender delivers rendered digests
+type Sender interface {
+	Send(msg *Message) error
+}
+
+// FileSender drops each digest as a file into a directory
+type FileSender struct {
+	Dir string
+	now func() time.Time
+}
+
+// NewFileSender creates a sender that writes digests into dir
+func NewFileSender(dir string) *FileSender {
+	return &FileSender{
+		Dir: dir,
+		now: time.Now,
+	}
+}
+
+// Send writes the message to <dir>/user<id>_<timestamp><ext>
+func (s *FileSender) Send(msg *Message) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("user%d_%s%s", msg.UserID, s.now().Format("20060102T150405"), msg.Format.Extension())
+
+	return os.WriteFile(filepath.Join(s.Dir, name), []byte(msg.Body), 0o644)
+}
+
+// SMTPSender sends digests through an SMTP server without authentication,
+// e.g. a local stand-in such as MailHog or smtp4dev
+type SMTPSender struct {
+	Addr   string
+	From   string
+	Domain string
+}
+
+// NewSMTPSender creates a sender that mails digests to user<id>@domain
+func NewSMTPSender(addr, from, domain string) *SMTPSender {
+	return &SMTPSender{
+		Addr:   addr,
+		From:   from,
+		Domain: domain,
+	}
+}
+
+// Send delivers the message by SMTP
+func (s *SMTPSender) Send(msg *Message) error {
+	to := fmt.Sprintf("user%d@%s", msg.UserID, s.Domain)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", msg.Format.ContentType())
+	fmt.Fprintf(&b, "\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return smtp.SendMail(s.Addr, nil, s.From, []string{to}, []byte(b.String()))
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"calendar/internal/digest"
+	"net/http"
+)
+
+// DigestHandler handles HTTP requests for agenda digests
+type DigestHandler struct {
+	builder *digest.Builder
+}
+
+// NewDigestHandler creates a new digest handler
+func NewDigestHandler(builder *digest.Builder) *DigestHandler {
+	return &DigestHandler{
+		builder: builder,
+	}
+}
+
+// GetDigest handles GET /agenda_digest
+func (h *DigestHandler) GetDigest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, date, err := parseQueryParams(r)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	period := digest.Period(r.URL.Query().Get("period"))
+	if period == "" {
+		period = digest.PeriodDay
+	}
+
+	format := digest.Format(r.URL.Query().Get("format"))
+	if format == "" {
+		format = digest.FormatText
+	}
+
+	d, err := h.builder.Build(userID, period, date)
+	if err != nil {
+		if err == digest.ErrInvalidPeriod {
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handleServiceError(w, err)
+		return
+	}
+
+	body, err := d.Render(format)
+	if err != nil {
+		if err == digest.ErrInvalidFormat {
+			sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sendError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(body))
+}
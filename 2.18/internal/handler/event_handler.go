@@ -2,25 +2,37 @@ package handler
 
 import (
 	"calendar/internal/model"
+	"calendar/internal/nldate"
 	"calendar/internal/service"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // EventHandler handles HTTP requests to the events API
 type EventHandler struct {
-	service *service.EventService
+	service  *service.EventService
+	location *time.Location
+	now      func() time.Time
 }
 
 // NewEventHandler creates a new event handler
 func NewEventHandler(service *service.EventService) *EventHandler {
 	return &EventHandler{
-		service: service,
+		service:  service,
+		location: time.UTC,
+		now:      time.Now,
 	}
 }
 
+// SetLocation sets the timezone used to resolve relative dates
+// when the request does not specify one
+func (h *EventHandler) SetLocation(loc *time.Location) {
+	h.location = loc
+}
+
 // CreateEvent handles POST /create_event
 func (h *EventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -34,9 +46,15 @@ func (h *EventHandler) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	event, err := h.service.CreateEvent(req.UserID, req.Date, req.EventText)
+	date, err := h.resolveDate(req.Date, req.Timezone)
+	if err != nil {
+		sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event, err := h.service.CreateEventAt(req.UserID, date, req.EventText)
 	if err != nil {
-		h.handleServiceError(w, err)
+		handleServiceError(w, err)
 		return
 	}
 
@@ -58,7 +76,7 @@ func (h *EventHandler) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 
 	event, err := h.service.UpdateEvent(req.ID, req.UserID, req.Date, req.EventText)
 	if err != nil {
-		h.handleServiceError(w, err)
+		handleServiceError(w, err)
 		return
 	}
 
@@ -80,7 +98,7 @@ func (h *EventHandler) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 
 	err := h.service.DeleteEvent(req.ID)
 	if err != nil {
-		h.handleServiceError(w, err)
+		handleServiceError(w, err)
 		return
 	}
 
@@ -94,7 +112,7 @@ func (h *EventHandler) GetEventsForDay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, date, err := h.parseQueryParams(r)
+	userID, date, err := parseQueryParams(r)
 	if err != nil {
 		sendError(w, err.Error(), http.StatusBadRequest)
 		return
@@ -102,7 +120,7 @@ func (h *EventHandler) GetEventsForDay(w http.ResponseWriter, r *http.Request) {
 
 	events, err := h.service.GetEventsForDay(userID, date)
 	if err != nil {
-		h.handleServiceError(w, err)
+		handleServiceError(w, err)
 		return
 	}
 
@@ -116,7 +134,7 @@ func (h *EventHandler) GetEventsForWeek(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userID, date, err := h.parseQueryParams(r)
+	userID, date, err := parseQueryParams(r)
 	if err != nil {
 		sendError(w, err.Error(), http.StatusBadRequest)
 		return
@@ -124,7 +142,7 @@ func (h *EventHandler) GetEventsForWeek(w http.ResponseWriter, r *http.Request)
 
 	events, err := h.service.GetEventsForWeek(userID, date)
 	if err != nil {
-		h.handleServiceError(w, err)
+		handleServiceError(w, err)
 		return
 	}
 
@@ -138,7 +156,7 @@ func (h *EventHandler) GetEventsForMonth(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	userID, date, err := h.parseQueryParams(r)
+	userID, date, err := parseQueryParams(r)
 	if err != nil {
 		sendError(w, err.Error(), http.StatusBadRequest)
 		return
@@ -146,13 +164,70 @@ func (h *EventHandler) GetEventsForMonth(w http.ResponseWriter, r *http.Request)
 
 	events, err := h.service.GetEventsForMonth(userID, date)
 	if err != nil {
-		h.handleServiceError(w, err)
+		handleServiceError(w, err)
+		return
+	}
+
+	sendSuccess(w, events, http.StatusOK)
+}
+
+// GetEventsForISOWeek handles GET /events_for_iso_week
+func (h *EventHandler) GetEventsForISOWeek(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userIDStr := r.URL.Query().Get("user_id")
+	if userIDStr == "" {
+		sendError(w, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	userID, err := strconv.Atoi(userIDStr)
+	if err != nil {
+		sendError(w, "invalid user_id", http.StatusBadRequest)
+		return
+	}
+
+	week := r.URL.Query().Get("week")
+	if week == "" {
+		sendError(w, "week is required", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.service.GetEventsForISOWeek(userID, week)
+	if err != nil {
+		handleServiceError(w, err)
 		return
 	}
 
 	sendSuccess(w, events, http.StatusOK)
 }
 
+// GetHolidays handles GET /holidays
+func (h *EventHandler) GetHolidays(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		sendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		sendError(w, "from and to are required", http.StatusBadRequest)
+		return
+	}
+
+	days, err := h.service.GetHolidays(from, to)
+	if err != nil {
+		handleServiceError(w, err)
+		return
+	}
+
+	sendSuccess(w, days, http.StatusOK)
+}
+
 func (h *EventHandler) parseRequest(r *http.Request, v interface{}) error {
 	contentType := r.Header.Get("Content-Type")
 
@@ -176,6 +251,7 @@ func (h *EventHandler) parseRequest(r *http.Request, v interface{}) error {
 		req.UserID = userID
 		req.Date = r.FormValue("date")
 		req.EventText = r.FormValue("event")
+		req.Timezone = r.FormValue("timezone")
 
 	case *model.UpdateEventRequest:
 		id, err := strconv.Atoi(r.FormValue("id"))
@@ -205,7 +281,7 @@ func (h *EventHandler) parseRequest(r *http.Request, v interface{}) error {
 	return nil
 }
 
-func (h *EventHandler) parseQueryParams(r *http.Request) (int, string, error) {
+func parseQueryParams(r *http.Request) (int, string, error) {
 	userIDStr := r.URL.Query().Get("user_id")
 	if userIDStr == "" {
 		return 0, "", errors.New("user_id is required")
@@ -224,9 +300,25 @@ func (h *EventHandler) parseQueryParams(r *http.Request) (int, string, error) {
 	return userID, date, nil
 }
 
-func (h *EventHandler) handleServiceError(w http.ResponseWriter, err error) {
+// resolveDate parses an ISO date or a natural-language phrase
+// relative to the current time in the user's timezone
+func (h *EventHandler) resolveDate(dateStr, timezone string) (time.Time, error) {
+	loc := h.location
+	if timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(timezone)
+		if err != nil {
+			return time.Time{}, errors.New("invalid timezone")
+		}
+	}
+
+	return nldate.Parse(dateStr, h.now().In(loc))
+}
+
+func handleServiceError(w http.ResponseWriter, err error) {
 	switch err {
-	case service.ErrInvalidDate, service.ErrInvalidUserID, service.ErrInvalidEventText:
+	case service.ErrInvalidDate, service.ErrInvalidUserID, service.ErrInvalidEventText,
+		service.ErrInvalidWeek, service.ErrInvalidRange:
 		sendError(w, err.Error(), http.StatusBadRequest)
 	case service.ErrEventNotFound:
 		sendError(w, err.Error(), http.StatusServiceUnavailable)
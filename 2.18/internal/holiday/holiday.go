@@ -0,0 +1,138 @@
+package holiday
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// DayType describes how a calendar day deviates from the regular week
+type DayType string
+
+const (
+	// TypeHoliday is a day off that would otherwise be a working day
+	TypeHoliday DayType = "holiday"
+	// TypeWorkday is a working day that would otherwise be a weekend (transferred day)
+	TypeWorkday DayType = "workday"
+)
+
+// Day is a single entry of the production calendar
+type Day struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name,omitempty"`
+	Type DayType   `json:"type"`
+}
+
+// Calendar is a production calendar with holidays and transferred working days
+type Calendar struct {
+	days map[string]Day
+}
+
+type fileDay struct {
+	Date string  `json:"date"`
+	Name string  `json:"name"`
+	Type DayType `json:"type"`
+}
+
+type file struct {
+	Days []fileDay `json:"days"`
+}
+
+// New creates an empty calendar where only Saturday and Sunday are days off
+func New() *Calendar {
+	return &Calendar{
+		days: make(map[string]Day),
+	}
+}
+
+// Load reads a calendar from a JSON file of the form
+//
+//	{"days": [{"date": "2024-01-01", "name": "Новый год", "type": "holiday"}]}
+func Load(path string) (*Calendar, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse decodes a calendar from JSON
+func Parse(data []byte) (*Calendar, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid holiday calendar: %w", err)
+	}
+
+	cal := New()
+	for _, d := range f.Days {
+		date, err := time.Parse(dateLayout, d.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday date %q", d.Date)
+		}
+
+		dayType := d.Type
+		if dayType == "" {
+			dayType = TypeHoliday
+		}
+		if dayType != TypeHoliday && dayType != TypeWorkday {
+			return nil, fmt.Errorf("invalid day type %q for %s", d.Type, d.Date)
+		}
+
+		cal.days[d.Date] = Day{Date: date, Name: d.Name, Type: dayType}
+	}
+
+	return cal, nil
+}
+
+// IsHoliday reports whether the date is a listed holiday
+func (c *Calendar) IsHoliday(date time.Time) bool {
+	day, ok := c.days[date.Format(dateLayout)]
+	return ok && day.Type == TypeHoliday
+}
+
+// IsWorkingDay reports whether the date is a working day
+// taking weekends, holidays and transferred days into account
+func (c *Calendar) IsWorkingDay(date time.Time) bool {
+	if day, ok := c.days[date.Format(dateLayout)]; ok {
+		return day.Type == TypeWorkday
+	}
+
+	weekday := date.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday
+}
+
+// Between returns calendar entries in the range [start, end) ordered by date
+func (c *Calendar) Between(start, end time.Time) []Day {
+	if !start.Before(end) {
+		return nil
+	}
+
+	var result []Day
+	for _, day := range c.days {
+		if !day.Date.Before(start) && day.Date.Before(end) {
+			result = append(result, day)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date.Before(result[j].Date)
+	})
+
+	return result
+}
+
+// Holidays returns only holidays in the range [start, end) ordered by date
+func (c *Calendar) Holidays(start, end time.Time) []Day {
+	var result []Day
+	for _, day := range c.Between(start, end) {
+		if day.Type == TypeHoliday {
+			result = append(result, day)
+		}
+	}
+	return result
+}
@@ -0,0 +1,79 @@
+package holiday
+
+import (
+	"testing"
+	"time"
+)
+
+const testCalendar = `{
+  "days": [
+    {"date": "2024-01-01", "name": "Новый год", "type": "holiday"},
+    {"date": "2024-01-08", "name": "Новогодние каникулы"},
+    {"date": "2024-04-27", "type": "workday"}
+  ]
+}`
+
+func date(s string) time.Time {
+	t, _ := time.Parse(dateLayout, s)
+	return t
+}
+
+func TestCalendar_IsWorkingDay(t *testing.T) {
+	cal, err := Parse([]byte(testCalendar))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		date string
+		want bool
+	}{
+		{name: "holiday on monday", date: "2024-01-01", want: false},
+		{name: "holiday without type", date: "2024-01-08", want: false},
+		{name: "regular tuesday", date: "2024-01-09", want: true},
+		{name: "regular saturday", date: "2024-01-13", want: false},
+		{name: "transferred saturday", date: "2024-04-27", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cal.IsWorkingDay(date(tt.date)); got != tt.want {
+				t.Errorf("IsWorkingDay(%s) = %v, want %v", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalendar_Holidays(t *testing.T) {
+	cal, err := Parse([]byte(testCalendar))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	days := cal.Holidays(date("2024-01-01"), date("2024-05-01"))
+	if len(days) != 2 {
+		t.Fatalf("Holidays() count = %v, want 2", len(days))
+	}
+	if !days[0].Date.Equal(date("2024-01-01")) || !days[1].Date.Equal(date("2024-01-08")) {
+		t.Errorf("Holidays() not ordered by date: %v", days)
+	}
+
+	if all := cal.Between(date("2024-01-01"), date("2024-05-01")); len(all) != 3 {
+		t.Errorf("Between() count = %v, want 3", len(all))
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	inputs := []string{
+		`not json`,
+		`{"days": [{"date": "01.01.2024"}]}`,
+		`{"days": [{"date": "2024-01-01", "type": "vacation"}]}`,
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Parse(%q) expected error", input)
+		}
+	}
+}
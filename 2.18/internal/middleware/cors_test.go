@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	options := CORSOptions{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Content-Type"},
+	}
+	wildcard := CORSOptions{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET"},
+		AllowedHeaders: []string{"Content-Type"},
+	}
+
+	tests := []struct {
+		name        string
+		opts        CORSOptions
+		method      string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantOrigin  string
+		wantMethods string
+		wantBody    bool
+	}{
+		{
+			name:        "allowed preflight",
+			opts:        options,
+			method:      http.MethodOptions,
+			origin:      "https://app.example.com",
+			preflight:   true,
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "https://app.example.com",
+			wantMethods: "GET, POST",
+		},
+		{
+			name:       "disallowed preflight",
+			opts:       options,
+			method:     http.MethodOptions,
+			origin:     "https://evil.example.com",
+			preflight:  true,
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "allowed request",
+			opts:       options,
+			method:     http.MethodGet,
+			origin:     "https://APP.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://APP.example.com",
+			wantBody:   true,
+		},
+		{
+			name:       "disallowed request passes without CORS headers",
+			opts:       options,
+			method:     http.MethodGet,
+			origin:     "https://evil.example.com",
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+		},
+		{
+			name:        "wildcard preflight",
+			opts:        wildcard,
+			method:      http.MethodOptions,
+			origin:      "https://any.example.com",
+			preflight:   true,
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "*",
+			wantMethods: "GET",
+		},
+		{
+			name:       "wildcard request",
+			opts:       wildcard,
+			method:     http.MethodPost,
+			origin:     "https://any.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "*",
+			wantBody:   true,
+		},
+		{
+			name:       "request without origin",
+			opts:       options,
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+		},
+		{
+			name:       "options without request method is not a preflight",
+			opts:       options,
+			method:     http.MethodOptions,
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://app.example.com",
+			wantBody:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/events_for_day", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+
+			CORS(tt.opts)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != tt.wantMethods {
+				t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, tt.wantMethods)
+			}
+			if tt.wantMethods != "" && rec.Header().Get("Access-Control-Max-Age") == "" {
+				t.Error("Access-Control-Max-Age is not set for preflight")
+			}
+			if (rec.Body.String() == "ok") != tt.wantBody {
+				t.Errorf("body = %q, want handler called = %v", rec.Body.String(), tt.wantBody)
+			}
+			if tt.origin != "" && len(rec.Header().Values("Vary")) == 0 {
+				t.Error("Vary header is not set for a request with Origin")
+			}
+		})
+	}
+}
@@ -8,13 +8,18 @@ type Event struct {
 	UserID    int       `json:"user_id"`
 	Date      time.Time `json:"date"`
 	EventText string    `json:"event"`
+	// Holiday marks a virtual all-day event generated from the holiday calendar
+	Holiday bool `json:"holiday,omitempty"`
 }
 
-// CreateEventRequest is a request structure for creating an event
+// CreateEventRequest is a request structure for creating an event.
+// Date is either YYYY-MM-DD or a phrase like "tomorrow 15:00" or "через 2 дня"
+// resolved in Timezone (IANA name, e.g. Europe/Moscow)
 type CreateEventRequest struct {
 	UserID    int    `json:"user_id"`
 	Date      string `json:"date"`
 	EventText string `json:"event"`
+	Timezone  string `json:"timezone,omitempty"`
 }
 
 // UpdateEventRequest is a request structure for updating an event
@@ -0,0 +1,411 @@
+// Package nldate parses human-friendly date phrases in English and Russian
+// such as "tomorrow 15:00", "next friday", "через 2 дня" or "in 3 weeks".
+package nldate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseError describes a phrase that could not be resolved to a date
+type ParseError struct {
+	Input string
+	// Unknown contains the words that were not understood
+	Unknown []string
+	// Reason explains why the phrase is ambiguous when all words were understood
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	if len(e.Unknown) > 0 {
+		quoted := make([]string, len(e.Unknown))
+		for i, word := range e.Unknown {
+			quoted[i] = strconv.Quote(word)
+		}
+		return fmt.Sprintf("cannot parse date %q: not understood: %s", e.Input, strings.Join(quoted, ", "))
+	}
+	return fmt.Sprintf("cannot parse date %q: %s", e.Input, e.Reason)
+}
+
+type unit int
+
+const (
+	unitMinute unit = iota
+	unitHour
+	unitDay
+	unitWeek
+	unitMonth
+	unitYear
+)
+
+var (
+	clockRe    = regexp.MustCompile(`^(\d{1,2}):(\d{2})(am|pm)?$`)
+	shortAmPm  = regexp.MustCompile(`^(\d{1,2})(am|pm)$`)
+	isoLayouts = []string{"2006-01-02", "2006-01-02t15:04", "02.01.2006"}
+)
+
+// fillers are words that carry no meaning on their own
+var fillers = map[string]bool{
+	"at": true, "on": true, "the": true, "of": true,
+	"в": true, "во": true, "на": true,
+}
+
+var relativeDays = map[string]int{
+	"today": 0, "tomorrow": 1, "yesterday": -1,
+	"сегодня": 0, "завтра": 1, "вчера": -1, "послезавтра": 2, "позавчера": -2,
+}
+
+var weekdays = map[string]time.Weekday{
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+	"sunday": time.Sunday, "sun": time.Sunday,
+
+	"понедельник": time.Monday,
+	"вторник":     time.Tuesday,
+	"среда":       time.Wednesday, "среду": time.Wednesday,
+	"четверг": time.Thursday,
+	"пятница": time.Friday, "пятницу": time.Friday,
+	"суббота": time.Saturday, "субботу": time.Saturday,
+	"воскресенье": time.Sunday,
+}
+
+var units = map[string]unit{
+	"minute": unitMinute, "minutes": unitMinute, "min": unitMinute,
+	"hour": unitHour, "hours": unitHour,
+	"day": unitDay, "days": unitDay,
+	"week": unitWeek, "weeks": unitWeek,
+	"month": unitMonth, "months": unitMonth,
+	"year": unitYear, "years": unitYear,
+
+	"минуту": unitMinute, "минуты": unitMinute, "минут": unitMinute,
+	"час": unitHour, "часа": unitHour, "часов": unitHour,
+	"день": unitDay, "дня": unitDay, "дней": unitDay,
+	"неделя": unitWeek, "неделю": unitWeek, "недели": unitWeek, "недель": unitWeek, "неделе": unitWeek,
+	"месяц": unitMonth, "месяца": unitMonth, "месяцев": unitMonth, "месяце": unitMonth,
+	"год": unitYear, "года": unitYear, "лет": unitYear, "году": unitYear,
+}
+
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+
+	"один": 1, "одну": 1, "одна": 1, "два": 2, "две": 2, "три": 3, "четыре": 4, "пять": 5,
+	"шесть": 6, "семь": 7, "восемь": 8, "девять": 9, "десять": 10,
+}
+
+var nextWords = map[string]bool{
+	"next": true, "following": true,
+	"следующий": true, "следующую": true, "следующая": true, "следующее": true, "следующей": true, "следующем": true,
+}
+
+var thisWords = map[string]bool{
+	"this": true, "coming": true,
+	"этот": true, "эту": true, "эта": true, "это": true, "этой": true, "этом": true,
+}
+
+var lastWords = map[string]bool{
+	"last": true, "previous": true,
+	"прошлый": true, "прошлую": true, "прошлая": true, "прошлое": true, "прошлой": true, "прошлом": true,
+}
+
+var namedClocks = map[string]int{
+	"noon": 12, "midnight": 0, "полдень": 12, "полночь": 0,
+}
+
+// resolver accumulates what was recognised in the phrase
+type resolver struct {
+	now  time.Time
+	date func(today time.Time) time.Time
+	// exact is true when the date already carries a time of day ("in 2 hours")
+	exact   bool
+	hour    int
+	minute  int
+	clock   bool
+	unknown []string
+	reason  string
+}
+
+// Parse resolves the phrase relative to now. The location of now
+// is used as the user's timezone. Phrases without a time of day
+// resolve to midnight
+func Parse(input string, now time.Time) (time.Time, error) {
+	text := strings.ToLower(strings.TrimSpace(input))
+	if text == "" {
+		return time.Time{}, &ParseError{Input: input, Reason: "date is empty"}
+	}
+
+	tokens := strings.Fields(strings.ReplaceAll(text, ",", " "))
+	r := &resolver{now: now}
+
+	for i := 0; i < len(tokens); {
+		i += r.consume(tokens, i)
+	}
+
+	if len(r.unknown) > 0 || r.reason != "" {
+		return time.Time{}, &ParseError{Input: input, Unknown: r.unknown, Reason: r.reason}
+	}
+
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+
+	if r.exact {
+		return r.date(today), nil
+	}
+
+	result := today
+	if r.date != nil {
+		result = r.date(today)
+	}
+	if r.clock {
+		result = time.Date(result.Year(), result.Month(), result.Day(), r.hour, r.minute, 0, 0, loc)
+	}
+
+	return result, nil
+}
+
+// consume recognises a construct starting at tokens[i] and returns
+// the number of tokens it used
+func (r *resolver) consume(tokens []string, i int) int {
+	tok := tokens[i]
+	next := func(k int) string {
+		if i+k < len(tokens) {
+			return tokens[i+k]
+		}
+		return ""
+	}
+
+	if fillers[tok] {
+		return 1
+	}
+
+	if date, ok := parseAbsolute(tok); ok {
+		r.setDate(func(today time.Time) time.Time {
+			return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, today.Location())
+		}, false)
+		if strings.Contains(tok, "t") {
+			r.setClock(date.Hour(), date.Minute())
+		}
+		return 1
+	}
+
+	if n, ok := r.consumeClock(tok, next(1)); ok {
+		return n
+	}
+
+	// "day after tomorrow" / "day before yesterday"
+	if tok == "day" && (next(1) == "after" && next(2) == "tomorrow" || next(1) == "before" && next(2) == "yesterday") {
+		offset := 2
+		if next(2) == "yesterday" {
+			offset = -2
+		}
+		r.setDate(func(today time.Time) time.Time { return today.AddDate(0, 0, offset) }, false)
+		return 3
+	}
+
+	if offset, ok := relativeDays[tok]; ok {
+		r.setDate(func(today time.Time) time.Time { return today.AddDate(0, 0, offset) }, false)
+		return 1
+	}
+
+	// "in 3 weeks", "in a week", "через 2 дня", "через неделю"
+	if tok == "in" || tok == "через" {
+		if u, ok := units[next(1)]; ok && tok == "через" {
+			r.setOffset(1, u)
+			return 2
+		}
+		if count, ok := parseCount(next(1)); ok {
+			if u, ok := units[next(2)]; ok {
+				r.setOffset(count, u)
+				return 3
+			}
+		}
+		r.unknown = append(r.unknown, tok)
+		return 1
+	}
+
+	// "3 days ago", "2 недели назад"
+	if count, ok := parseCount(tok); ok {
+		if u, ok := units[next(1)]; ok && (next(2) == "ago" || next(2) == "назад") {
+			r.setOffset(-count, u)
+			return 3
+		}
+	}
+
+	if nextWords[tok] || thisWords[tok] || lastWords[tok] {
+		if day, ok := weekdays[next(1)]; ok {
+			switch {
+			case nextWords[tok]:
+				r.setWeekday(day, 1)
+			case lastWords[tok]:
+				r.setWeekday(day, -1)
+			default:
+				r.setWeekday(day, 0)
+			}
+			return 2
+		}
+		if u, ok := units[next(1)]; ok && u >= unitDay {
+			switch {
+			case nextWords[tok]:
+				r.setOffset(1, u)
+			case lastWords[tok]:
+				r.setOffset(-1, u)
+			default:
+				r.setOffset(0, u)
+			}
+			return 2
+		}
+		r.unknown = append(r.unknown, tok)
+		return 1
+	}
+
+	if day, ok := weekdays[tok]; ok {
+		r.setWeekday(day, 0)
+		return 1
+	}
+
+	r.unknown = append(r.unknown, tok)
+	return 1
+}
+
+// consumeClock recognises "15:00", "3pm", "3 pm", "3:30 pm" and "noon"
+func (r *resolver) consumeClock(tok, following string) (int, bool) {
+	if hour, ok := namedClocks[tok]; ok {
+		r.setClock(hour, 0)
+		return 1, true
+	}
+
+	if m := clockRe.FindStringSubmatch(tok); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		suffix, used := m[3], 1
+		if suffix == "" && (following == "am" || following == "pm") {
+			suffix, used = following, 2
+		}
+		r.setClock12(hour, minute, suffix, tok)
+		return used, true
+	}
+
+	if m := shortAmPm.FindStringSubmatch(tok); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		r.setClock12(hour, 0, m[2], tok)
+		return 1, true
+	}
+
+	if hour, err := strconv.Atoi(tok); err == nil && (following == "am" || following == "pm") {
+		r.setClock12(hour, 0, following, tok)
+		return 2, true
+	}
+
+	return 0, false
+}
+
+func (r *resolver) setClock12(hour, minute int, suffix, tok string) {
+	if suffix != "" {
+		if hour < 1 || hour > 12 {
+			r.unknown = append(r.unknown, tok)
+			return
+		}
+		hour %= 12
+		if suffix == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		r.unknown = append(r.unknown, tok)
+		return
+	}
+	r.setClock(hour, minute)
+}
+
+func (r *resolver) setClock(hour, minute int) {
+	if r.clock {
+		r.conflict("more than one time of day")
+		return
+	}
+	r.clock = true
+	r.hour = hour
+	r.minute = minute
+	if r.exact {
+		r.conflict("time of day combined with an hour or minute offset")
+	}
+}
+
+func (r *resolver) setDate(date func(today time.Time) time.Time, exact bool) {
+	if r.date != nil {
+		r.conflict("more than one date")
+		return
+	}
+	r.date = date
+	r.exact = exact
+	if exact && r.clock {
+		r.conflict("time of day combined with an hour or minute offset")
+	}
+}
+
+func (r *resolver) setOffset(count int, u unit) {
+	now := r.now
+	switch u {
+	case unitMinute:
+		r.setDate(func(time.Time) time.Time { return now.Add(time.Duration(count) * time.Minute) }, true)
+	case unitHour:
+		r.setDate(func(time.Time) time.Time { return now.Add(time.Duration(count) * time.Hour) }, true)
+	case unitDay:
+		r.setDate(func(today time.Time) time.Time { return today.AddDate(0, 0, count) }, false)
+	case unitWeek:
+		r.setDate(func(today time.Time) time.Time { return today.AddDate(0, 0, 7*count) }, false)
+	case unitMonth:
+		r.setDate(func(today time.Time) time.Time { return today.AddDate(0, count, 0) }, false)
+	case unitYear:
+		r.setDate(func(today time.Time) time.Time { return today.AddDate(count, 0, 0) }, false)
+	}
+}
+
+// setWeekday sets the date to the given weekday. direction 0 is the nearest
+// such day including today, 1 is strictly after today, -1 strictly before today
+func (r *resolver) setWeekday(day time.Weekday, direction int) {
+	r.setDate(func(today time.Time) time.Time {
+		diff := (int(day) - int(today.Weekday()) + 7) % 7
+		switch direction {
+		case 1:
+			if diff == 0 {
+				diff = 7
+			}
+		case -1:
+			diff -= 7
+		}
+		return today.AddDate(0, 0, diff)
+	}, false)
+}
+
+func (r *resolver) conflict(reason string) {
+	if r.reason == "" {
+		r.reason = reason
+	}
+}
+
+func parseAbsolute(tok string) (time.Time, bool) {
+	for _, layout := range isoLayouts {
+		if date, err := time.Parse(layout, tok); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func parseCount(tok string) (int, bool) {
+	if n, ok := numberWords[tok]; ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(tok)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,171 @@
+package nldate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var msk = time.FixedZone("MSK", 3*60*60)
+
+// now is Wednesday, 17 January 2024, 10:30 MSK
+var now = time.Date(2024, time.January, 17, 10, 30, 0, 0, msk)
+
+func at(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, msk)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		// Absolute dates
+		{"2024-01-15", at(2024, 1, 15, 0, 0)},
+		{"2024-01-15 15:00", at(2024, 1, 15, 15, 0)},
+		{"2024-01-15T09:45", at(2024, 1, 15, 9, 45)},
+		{"15.01.2024", at(2024, 1, 15, 0, 0)},
+		{"  2024-02-29  ", at(2024, 2, 29, 0, 0)},
+
+		// Relative days
+		{"today", at(2024, 1, 17, 0, 0)},
+		{"Tomorrow", at(2024, 1, 18, 0, 0)},
+		{"yesterday", at(2024, 1, 16, 0, 0)},
+		{"day after tomorrow", at(2024, 1, 19, 0, 0)},
+		{"day before yesterday", at(2024, 1, 15, 0, 0)},
+		{"сегодня", at(2024, 1, 17, 0, 0)},
+		{"завтра", at(2024, 1, 18, 0, 0)},
+		{"вчера", at(2024, 1, 16, 0, 0)},
+		{"послезавтра", at(2024, 1, 19, 0, 0)},
+		{"позавчера", at(2024, 1, 15, 0, 0)},
+
+		// Time of day
+		{"tomorrow 15:00", at(2024, 1, 18, 15, 0)},
+		{"15:00 tomorrow", at(2024, 1, 18, 15, 0)},
+		{"tomorrow at 3pm", at(2024, 1, 18, 15, 0)},
+		{"tomorrow at 3 pm", at(2024, 1, 18, 15, 0)},
+		{"tomorrow 9:15am", at(2024, 1, 18, 9, 15)},
+		{"tomorrow 9:15 am", at(2024, 1, 18, 9, 15)},
+		{"today 12am", at(2024, 1, 17, 0, 0)},
+		{"today 12pm", at(2024, 1, 17, 12, 0)},
+		{"tomorrow noon", at(2024, 1, 18, 12, 0)},
+		{"tomorrow at midnight", at(2024, 1, 18, 0, 0)},
+		{"18:30", at(2024, 1, 17, 18, 30)},
+		{"завтра в 15:00", at(2024, 1, 18, 15, 0)},
+		{"завтра в полдень", at(2024, 1, 18, 12, 0)},
+		{"сегодня в полночь", at(2024, 1, 17, 0, 0)},
+
+		// Weekdays (today is Wednesday)
+		{"friday", at(2024, 1, 19, 0, 0)},
+		{"on friday", at(2024, 1, 19, 0, 0)},
+		{"this friday", at(2024, 1, 19, 0, 0)},
+		{"next friday", at(2024, 1, 19, 0, 0)},
+		{"next monday", at(2024, 1, 22, 0, 0)},
+		{"wednesday", at(2024, 1, 17, 0, 0)},
+		{"next wednesday", at(2024, 1, 24, 0, 0)},
+		{"last wednesday", at(2024, 1, 10, 0, 0)},
+		{"last friday", at(2024, 1, 12, 0, 0)},
+		{"next fri 10:00", at(2024, 1, 19, 10, 0)},
+		{"в пятницу", at(2024, 1, 19, 0, 0)},
+		{"в следующую пятницу", at(2024, 1, 19, 0, 0)},
+		{"в следующую среду", at(2024, 1, 24, 0, 0)},
+		{"в эту субботу", at(2024, 1, 20, 0, 0)},
+		{"в прошлый понедельник", at(2024, 1, 15, 0, 0)},
+		{"воскресенье в 11:00", at(2024, 1, 21, 11, 0)},
+
+		// Offsets
+		{"in 3 weeks", at(2024, 2, 7, 0, 0)},
+		{"in a week", at(2024, 1, 24, 0, 0)},
+		{"in two days", at(2024, 1, 19, 0, 0)},
+		{"in 1 month", at(2024, 2, 17, 0, 0)},
+		{"in 2 years", at(2026, 1, 17, 0, 0)},
+		{"in 2 hours", at(2024, 1, 17, 12, 30)},
+		{"in 45 minutes", at(2024, 1, 17, 11, 15)},
+		{"in 3 days at 9:00", at(2024, 1, 20, 9, 0)},
+		{"3 days ago", at(2024, 1, 14, 0, 0)},
+		{"a week ago", at(2024, 1, 10, 0, 0)},
+		{"next week", at(2024, 1, 24, 0, 0)},
+		{"next month", at(2024, 2, 17, 0, 0)},
+		{"last year", at(2023, 1, 17, 0, 0)},
+		{"через 2 дня", at(2024, 1, 19, 0, 0)},
+		{"через день", at(2024, 1, 18, 0, 0)},
+		{"через неделю", at(2024, 1, 24, 0, 0)},
+		{"через две недели", at(2024, 1, 31, 0, 0)},
+		{"через 5 дней в 18:00", at(2024, 1, 22, 18, 0)},
+		{"через месяц", at(2024, 2, 17, 0, 0)},
+		{"через 3 часа", at(2024, 1, 17, 13, 30)},
+		{"через 10 минут", at(2024, 1, 17, 10, 40)},
+		{"через год", at(2025, 1, 17, 0, 0)},
+		{"2 недели назад", at(2024, 1, 3, 0, 0)},
+		{"на следующей неделе", at(2024, 1, 24, 0, 0)},
+		{"в следующем месяце", at(2024, 2, 17, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input, now)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != msk {
+				t.Errorf("Parse(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		input       string
+		wantUnknown []string
+		wantReason  string
+	}{
+		{input: "", wantReason: "date is empty"},
+		{input: "   ", wantReason: "date is empty"},
+		{input: "someday", wantUnknown: []string{"someday"}},
+		{input: "tomorrow evening", wantUnknown: []string{"evening"}},
+		{input: "next blursday at 25:00", wantUnknown: []string{"next", "blursday", "25:00"}},
+		{input: "in 3 fortnights", wantUnknown: []string{"in", "3", "fortnights"}},
+		{input: "через пару дней", wantUnknown: []string{"через", "пару", "дней"}},
+		{input: "31-12-2023", wantUnknown: []string{"31-12-2023"}},
+		{input: "2024-02-30", wantUnknown: []string{"2024-02-30"}},
+		{input: "13pm", wantUnknown: []string{"13pm"}},
+		{input: "12:75", wantUnknown: []string{"12:75"}},
+		{input: "tomorrow friday", wantReason: "more than one date"},
+		{input: "2024-01-15 next week", wantReason: "more than one date"},
+		{input: "15:00 16:00", wantReason: "more than one time of day"},
+		{input: "in 2 hours at 15:00", wantReason: "time of day combined with an hour or minute offset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(tt.input, now)
+
+			var perr *ParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("Parse(%q) error = %v, want *ParseError", tt.input, err)
+			}
+			if !reflect.DeepEqual(perr.Unknown, tt.wantUnknown) {
+				t.Errorf("Parse(%q) unknown = %q, want %q", tt.input, perr.Unknown, tt.wantUnknown)
+			}
+			if perr.Reason != tt.wantReason {
+				t.Errorf("Parse(%q) reason = %q, want %q", tt.input, perr.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestParseError_Error(t *testing.T) {
+	_, err := Parse("tomorrow evening later", now)
+	want := `cannot parse date "tomorrow evening later": not understood: "evening", "later"`
+	if err == nil || err.Error() != want {
+		t.Errorf("Error() = %v, want %v", err, want)
+	}
+
+	_, err = Parse("today tomorrow", now)
+	want = `cannot parse date "today tomorrow": more than one date`
+	if err == nil || err.Error() != want {
+		t.Errorf("Error() = %v, want %v", err, want)
+	}
+}
@@ -1,8 +1,13 @@
 package service
 
 import (
+	"calendar/internal/holiday"
 	"calendar/internal/model"
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -16,32 +21,59 @@ var (
 	ErrInvalidUserID = errors.New("invalid user_id")
 	// ErrInvalidEventText is returned when event text is empty
 	ErrInvalidEventText = errors.New("event text cannot be empty")
+	// ErrInvalidWeek is returned when ISO week format is invalid
+	ErrInvalidWeek = errors.New("invalid week format, expected YYYY-Www")
+	// ErrInvalidRange is returned when the end of a date range is before its start
+	ErrInvalidRange = errors.New("invalid date range")
 )
 
 // EventService implements business logic for working with events
 type EventService struct {
-	mu         sync.RWMutex
-	events     map[int]*model.Event
-	nextID     int
-	userEvents map[int][]*model.Event
+	store *store
+
+	// mu guards the settings below
+	mu sync.RWMutex
+
+	// alignWeek makes GetEventsForWeek use calendar weeks starting on weekStart
+	// instead of 7 days from the requested date
+	alignWeek bool
+	weekStart time.Weekday
+
+	holidays         *holiday.Calendar
+	holidaysAsEvents bool
 }
 
 // NewEventService creates a new instance of event service
 func NewEventService() *EventService {
 	return &EventService{
-		events:     make(map[int]*model.Event),
-		nextID:     1,
-		userEvents: make(map[int][]*model.Event),
+		store: newStore(),
 	}
 }
 
+// SetWeekStart makes GetEventsForWeek return the calendar week
+// that starts on the given weekday and contains the requested date
+func (s *EventService) SetWeekStart(day time.Weekday) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.alignWeek = true
+	s.weekStart = day
+}
+
+// SetHolidayCalendar sets the holiday calendar.
+// If asEvents is true, holidays are included in event listings as virtual all-day events
+func (s *EventService) SetHolidayCalendar(cal *holiday.Calendar, asEvents bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.holidays = cal
+	s.holidaysAsEvents = asEvents
+}
+
 // CreateEvent creates a new event
 func (s *EventService) CreateEvent(userID int, dateStr, eventText string) (*model.Event, error) {
-	if userID <= 0 {
-		return nil, ErrInvalidUserID
-	}
-	if eventText == "" {
-		return nil, ErrInvalidEventText
+	if err := validateEvent(userID, eventText); err != nil {
+		return nil, err
 	}
 
 	date, err := time.Parse("2006-01-02", dateStr)
@@ -49,30 +81,24 @@ func (s *EventService) CreateEvent(userID int, dateStr, eventText string) (*mode
 		return nil, ErrInvalidDate
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.store.create(userID, date, eventText), nil
+}
 
-	event := &model.Event{
-		ID:        s.nextID,
-		UserID:    userID,
-		Date:      date,
-		EventText: eventText,
+// CreateEventAt creates a new event for an already resolved date.
+// The date keeps its location, while events are grouped and ordered
+// by the wall-clock date and time in the user's own timezone
+func (s *EventService) CreateEventAt(userID int, date time.Time, eventText string) (*model.Event, error) {
+	if err := validateEvent(userID, eventText); err != nil {
+		return nil, err
 	}
-	s.nextID++
-
-	s.events[event.ID] = event
-	s.userEvents[userID] = append(s.userEvents[userID], event)
 
-	return event, nil
+	return s.store.create(userID, date, eventText), nil
 }
 
 // UpdateEvent updates an existing event
 func (s *EventService) UpdateEvent(id, userID int, dateStr, eventText string) (*model.Event, error) {
-	if userID <= 0 {
-		return nil, ErrInvalidUserID
-	}
-	if eventText == "" {
-		return nil, ErrInvalidEventText
+	if err := validateEvent(userID, eventText); err != nil {
+		return nil, err
 	}
 
 	date, err := time.Parse("2006-01-02", dateStr)
@@ -80,40 +106,23 @@ func (s *EventService) UpdateEvent(id, userID int, dateStr, eventText string) (*
 		return nil, ErrInvalidDate
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return s.store.update(id, userID, date, eventText)
+}
 
-	event, exists := s.events[id]
-	if !exists {
-		return nil, ErrEventNotFound
+// validateEvent checks the fields shared by all event writes
+func validateEvent(userID int, eventText string) error {
+	if userID <= 0 {
+		return ErrInvalidUserID
 	}
-
-	s.removeFromUserIndex(event)
-
-	event.UserID = userID
-	event.Date = date
-	event.EventText = eventText
-
-	s.userEvents[userID] = append(s.userEvents[userID], event)
-
-	return event, nil
+	if eventText == "" {
+		return ErrInvalidEventText
+	}
+	return nil
 }
 
 // DeleteEvent deletes an event
 func (s *EventService) DeleteEvent(id int) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	event, exists := s.events[id]
-	if !exists {
-		return ErrEventNotFound
-	}
-
-	s.removeFromUserIndex(event)
-
-	delete(s.events, id)
-
-	return nil
+	return s.store.delete(id)
 }
 
 // GetEventsForDay returns all events for a user on the specified day
@@ -123,39 +132,52 @@ func (s *EventService) GetEventsForDay(userID int, dateStr string) ([]*model.Eve
 		return nil, ErrInvalidDate
 	}
 
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	var result []*model.Event
-	for _, event := range s.userEvents[userID] {
-		if isSameDay(event.Date, date) {
-			result = append(result, event)
-		}
-	}
+	events := s.store.onDay(userID, date)
 
-	return result, nil
+	return s.withHolidays(events, userID, date, date.AddDate(0, 0, 1)), nil
 }
 
-// GetEventsForWeek returns all events for a user for the week (7 days from the specified date)
+// GetEventsForWeek returns all events for a user for the week.
+// By default the week is 7 days from the specified date; after SetWeekStart
+// it is the calendar week containing the date
 func (s *EventService) GetEventsForWeek(userID int, dateStr string) ([]*model.Event, error) {
-	startDate, err := time.Parse("2006-01-02", dateStr)
+	date, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
 		return nil, ErrInvalidDate
 	}
 
-	endDate := startDate.AddDate(0, 0, 7)
+	startDate, endDate := s.WeekRange(date)
 
+	return s.eventsInRange(userID, startDate, endDate), nil
+}
+
+// WeekRange returns the bounds [start, end) of the week that
+// GetEventsForWeek uses for the given date
+func (s *EventService) WeekRange(date time.Time) (time.Time, time.Time) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var result []*model.Event
-	for _, event := range s.userEvents[userID] {
-		if (event.Date.Equal(startDate) || event.Date.After(startDate)) && event.Date.Before(endDate) {
-			result = append(result, event)
-		}
+	startDate := date
+	if s.alignWeek {
+		startDate = startOfWeek(date, s.weekStart)
+	}
+	return startDate, startDate.AddDate(0, 0, 7)
+}
+
+// UserIDs returns IDs of all users that have at least one event, in ascending order
+func (s *EventService) UserIDs() []int {
+	return s.store.userIDs()
+}
+
+// GetEventsForISOWeek returns all events for a user for the ISO week
+// in the format YYYY-Www (for example 2024-W03). ISO weeks always start on Monday
+func (s *EventService) GetEventsForISOWeek(userID int, weekStr string) ([]*model.Event, error) {
+	startDate, err := parseISOWeek(weekStr)
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return s.eventsInRange(userID, startDate, startDate.AddDate(0, 0, 7)), nil
 }
 
 // GetEventsForMonth returns all events for a user for the month
@@ -169,27 +191,122 @@ func (s *EventService) GetEventsForMonth(userID int, dateStr string) ([]*model.E
 	startDate := time.Date(year, month, 1, 0, 0, 0, 0, date.Location())
 	endDate := startDate.AddDate(0, 1, 0)
 
+	return s.eventsInRange(userID, startDate, endDate), nil
+}
+
+// GetHolidays returns holiday calendar entries between from and to inclusive
+func (s *EventService) GetHolidays(fromStr, toStr string) ([]holiday.Day, error) {
+	from, err := time.Parse("2006-01-02", fromStr)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	to, err := time.Parse("2006-01-02", toStr)
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	if to.Before(from) {
+		return nil, ErrInvalidRange
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	var result []*model.Event
-	for _, event := range s.userEvents[userID] {
-		if (event.Date.Equal(startDate) || event.Date.After(startDate)) && event.Date.Before(endDate) {
-			result = append(result, event)
+	if s.holidays == nil {
+		return []holiday.Day{}, nil
+	}
+
+	days := s.holidays.Between(from, to.AddDate(0, 0, 1))
+	if days == nil {
+		days = []holiday.Day{}
+	}
+
+	return days, nil
+}
+
+// eventsInRange returns user events in [startDate, endDate) ordered by date
+func (s *EventService) eventsInRange(userID int, startDate, endDate time.Time) []*model.Event {
+	events := s.store.between(userID, startDate, endDate)
+
+	return s.withHolidays(events, userID, startDate, endDate)
+}
+
+// withHolidays merges virtual holiday events in [startDate, endDate) into events
+// ordered by date when enabled. A holiday goes after events at the same time
+func (s *EventService) withHolidays(events []*model.Event, userID int, startDate, endDate time.Time) []*model.Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.holidays == nil || !s.holidaysAsEvents {
+		return events
+	}
+
+	days := s.holidays.Holidays(startDate, endDate)
+	if len(days) == 0 {
+		return events
+	}
+
+	result := make([]*model.Event, 0, len(events)+len(days))
+	i := 0
+	for _, day := range days {
+		for i < len(events) && !wallClock(events[i].Date).After(day.Date) {
+			result = append(result, events[i])
+			i++
 		}
+		result = append(result, &model.Event{
+			UserID:    userID,
+			Date:      day.Date,
+			EventText: day.Name,
+			Holiday:   true,
+		})
 	}
 
-	return result, nil
+	return append(result, events[i:]...)
 }
 
-func (s *EventService) removeFromUserIndex(event *model.Event) {
-	events := s.userEvents[event.UserID]
-	for i, e := range events {
-		if e.ID == event.ID {
-			s.userEvents[event.UserID] = append(events[:i], events[i+1:]...)
-			break
+// ParseWeekday parses an English weekday name such as "monday" or "Sun"
+func ParseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, nil
 		}
 	}
+	return 0, fmt.Errorf("unknown weekday %q", name)
+}
+
+// startOfWeek returns the closest day on or before date that falls on weekStart
+func startOfWeek(date time.Time, weekStart time.Weekday) time.Time {
+	offset := (int(date.Weekday()) - int(weekStart) + 7) % 7
+	return date.AddDate(0, 0, -offset)
+}
+
+var isoWeekRe = regexp.MustCompile(`^(\d{4})-W(\d{2})$`)
+
+// parseISOWeek returns the Monday of the ISO week in the format YYYY-Www
+func parseISOWeek(weekStr string) (time.Time, error) {
+	m := isoWeekRe.FindStringSubmatch(weekStr)
+	if m == nil {
+		return time.Time{}, ErrInvalidWeek
+	}
+	year, _ := strconv.Atoi(m[1])
+	week, _ := strconv.Atoi(m[2])
+
+	// January 4th is always in the first ISO week
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := startOfWeek(jan4, time.Monday).AddDate(0, 0, (week-1)*7)
+
+	if y, w := monday.ISOWeek(); y != year || w != week {
+		return time.Time{}, ErrInvalidWeek
+	}
+
+	return monday, nil
+}
+
+// wallClock drops the timezone keeping the local date and time.
+// Events are compared by it with dates parsed from YYYY-MM-DD in UTC
+func wallClock(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), date.Hour(), date.Minute(), date.Second(), 0, time.UTC)
 }
 
 func isSameDay(date1, date2 time.Time) bool {
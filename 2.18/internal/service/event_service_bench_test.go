@@ -0,0 +1,143 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+var benchSizes = []int{100, 1000, 10000}
+
+// newBenchService creates a service with one user owning n events
+// spread over a year starting on 2024-01-01
+func newBenchService(b *testing.B, n int) (*EventService, []int) {
+	b.Helper()
+
+	service := NewEventService()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ids := make([]int, n)
+	for i := 0; i < n; i++ {
+		date := start.AddDate(0, 0, i%365).Format("2006-01-02")
+		event, err := service.CreateEvent(1, date, "Event")
+		if err != nil {
+			b.Fatal(err)
+		}
+		ids[i] = event.ID
+	}
+
+	return service, ids
+}
+
+func BenchmarkEventService_CreateEvent(b *testing.B) {
+	service := NewEventService()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		service.CreateEvent(1+i%100, "2024-01-15", "Event")
+	}
+}
+
+func BenchmarkEventService_UpdateEvent(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("events=%d", n), func(b *testing.B) {
+			service, ids := newBenchService(b, n)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Обновляем самые старые события, перенося их в середину индекса
+				service.UpdateEvent(ids[i%n], 1, "2024-06-01", "Updated")
+			}
+		})
+	}
+}
+
+func BenchmarkEventService_DeleteEvent(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("events=%d", n), func(b *testing.B) {
+			service, ids := newBenchService(b, n)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				id := ids[i%n]
+				service.DeleteEvent(id)
+
+				b.StopTimer()
+				event, _ := service.CreateEvent(1, "2024-06-01", "Event")
+				ids[i%n] = event.ID
+				b.StartTimer()
+			}
+		})
+	}
+}
+
+func BenchmarkEventService_GetEventsForDay(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("events=%d", n), func(b *testing.B) {
+			service, _ := newBenchService(b, n)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				service.GetEventsForDay(1, "2024-03-15")
+			}
+		})
+	}
+}
+
+func BenchmarkEventService_GetEventsForWeek(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("events=%d", n), func(b *testing.B) {
+			service, _ := newBenchService(b, n)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				service.GetEventsForWeek(1, "2024-03-15")
+			}
+		})
+	}
+}
+
+func BenchmarkEventService_GetEventsForMonth(b *testing.B) {
+	for _, n := range benchSizes {
+		b.Run(fmt.Sprintf("events=%d", n), func(b *testing.B) {
+			service, _ := newBenchService(b, n)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				service.GetEventsForMonth(1, "2024-03-15")
+			}
+		})
+	}
+}
+
+// BenchmarkEventService_Mixed measures contention between many users:
+// 20% creates, 10% updates and 70% range queries
+func BenchmarkEventService_Mixed(b *testing.B) {
+	service := NewEventService()
+	for user := 1; user <= 100; user++ {
+		for day := 1; day <= 28; day++ {
+			service.CreateEvent(user, fmt.Sprintf("2024-02-%02d", day), "Event")
+		}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			user := 1 + i%100
+			switch i % 10 {
+			case 0, 1:
+				service.CreateEvent(user, "2024-02-10", "Event")
+			case 2:
+				service.UpdateEvent(1+i%2800, user, "2024-02-11", "Updated")
+			case 3, 4, 5:
+				service.GetEventsForDay(user, "2024-02-10")
+			case 6, 7:
+				service.GetEventsForWeek(user, "2024-02-05")
+			default:
+				service.GetEventsForMonth(user, "2024-02-01")
+			}
+			i++
+		}
+	})
+}
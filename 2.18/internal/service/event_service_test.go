@@ -1,6 +1,11 @@
 package service
 
 import (
+	"calendar/internal/holiday"
+	"calendar/internal/model"
+	"encoding/json"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -70,6 +75,55 @@ func TestEventService_CreateEvent(t *testing.T) {
 	}
 }
 
+func TestEventService_CreateEventAt(t *testing.T) {
+	service := NewEventService()
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	// 08:00 in Tokyo is still the previous day in UTC
+	morning, err := service.CreateEventAt(1, time.Date(2026, 10, 20, 8, 0, 0, 0, tokyo), "Tokyo morning")
+	if err != nil {
+		t.Fatalf("CreateEventAt() error = %v", err)
+	}
+	service.CreateEventAt(1, time.Date(2026, 10, 20, 7, 0, 0, 0, time.UTC), "UTC morning")
+	service.CreateEventAt(1, time.Date(2026, 10, 20, 15, 0, 0, 0, tokyo), "Tokyo afternoon")
+
+	data, err := json.Marshal(morning)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"date":"2026-10-20T08:00:00+09:00"`) {
+		t.Errorf("json.Marshal() = %s, want the Tokyo offset", data)
+	}
+
+	events, _ := service.GetEventsForDay(1, "2026-10-20")
+	want := []string{"UTC morning", "Tokyo morning", "Tokyo afternoon"}
+	var got []string
+	for _, event := range events {
+		got = append(got, event.EventText)
+	}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("GetEventsForDay() = %v, want %v", got, want)
+	}
+
+	if events, _ := service.GetEventsForDay(1, "2026-10-19"); len(events) != 0 {
+		t.Errorf("GetEventsForDay(previous day) count = %v, want 0", len(events))
+	}
+	if events, _ := service.GetEventsForMonth(1, "2026-10-01"); len(events) != 3 {
+		t.Errorf("GetEventsForMonth() count = %v, want 3", len(events))
+	}
+
+	if _, err := service.CreateEventAt(0, time.Now(), "Event"); err != ErrInvalidUserID {
+		t.Errorf("CreateEventAt() error = %v, want %v", err, ErrInvalidUserID)
+	}
+	if _, err := service.CreateEventAt(1, time.Now(), ""); err != ErrInvalidEventText {
+		t.Errorf("CreateEventAt() error = %v, want %v", err, ErrInvalidEventText)
+	}
+}
+
 func TestEventService_UpdateEvent(t *testing.T) {
 	service := NewEventService()
 
@@ -301,3 +355,236 @@ func TestIsSameDay(t *testing.T) {
 		t.Error("isSameDay() should return false for different days")
 	}
 }
+
+func TestEventService_GetEventsForWeek_WeekStart(t *testing.T) {
+	service := NewEventService()
+	service.SetWeekStart(time.Monday)
+
+	service.CreateEvent(1, "2023-12-31", "Event 1") // Воскресенье прошлой недели
+	service.CreateEvent(1, "2024-01-01", "Event 2")
+	service.CreateEvent(1, "2024-01-07", "Event 3")
+	service.CreateEvent(1, "2024-01-08", "Event 4") // Следующая неделя
+
+	events, err := service.GetEventsForWeek(1, "2024-01-03")
+	if err != nil {
+		t.Errorf("GetEventsForWeek() error = %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Errorf("GetEventsForWeek() count = %v, want 2", len(events))
+	}
+}
+
+func TestEventService_GetEventsForISOWeek(t *testing.T) {
+	service := NewEventService()
+
+	service.CreateEvent(1, "2024-01-01", "Event 1") // 2024-W01
+	service.CreateEvent(1, "2024-01-15", "Event 2") // 2024-W03
+	service.CreateEvent(1, "2024-01-21", "Event 3") // 2024-W03
+	service.CreateEvent(1, "2024-12-30", "Event 4") // 2025-W01
+
+	tests := []struct {
+		name      string
+		week      string
+		wantCount int
+		wantErr   error
+	}{
+		{name: "first week", week: "2024-W01", wantCount: 1},
+		{name: "third week", week: "2024-W03", wantCount: 2},
+		{name: "week belongs to next year", week: "2025-W01", wantCount: 1},
+		{name: "week out of range", week: "2024-W53", wantErr: ErrInvalidWeek},
+		{name: "invalid format", week: "2024-03", wantErr: ErrInvalidWeek},
+		{name: "week zero", week: "2024-W00", wantErr: ErrInvalidWeek},
+		{name: "trailing garbage", week: "2024-W5x", wantErr: ErrInvalidWeek},
+		{name: "trailing space", week: "2024-W1 ", wantErr: ErrInvalidWeek},
+		{name: "signed year", week: "+024-W05", wantErr: ErrInvalidWeek},
+		{name: "one digit week", week: "2024-W1", wantErr: ErrInvalidWeek},
+		{name: "lowercase w", week: "2024-w01", wantErr: ErrInvalidWeek},
+		{name: "non-ascii digits", week: "2024-W٠١", wantErr: ErrInvalidWeek},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := service.GetEventsForISOWeek(1, tt.week)
+
+			if err != tt.wantErr {
+				t.Errorf("GetEventsForISOWeek() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if tt.wantErr == nil && len(events) != tt.wantCount {
+				t.Errorf("GetEventsForISOWeek() count = %v, want %v", len(events), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestEventService_HolidaysAsEvents(t *testing.T) {
+	service := NewEventService()
+
+	cal, err := holiday.Parse([]byte(`{"days": [
+		{"date": "2024-01-01", "name": "Новый год"},
+		{"date": "2024-04-27", "type": "workday"}
+	]}`))
+	if err != nil {
+		t.Fatalf("holiday.Parse() error = %v", err)
+	}
+	service.SetHolidayCalendar(cal, true)
+
+	service.CreateEvent(1, "2024-01-01", "Event 1")
+
+	events, _ := service.GetEventsForDay(1, "2024-01-01")
+	if len(events) != 2 {
+		t.Fatalf("GetEventsForDay() count = %v, want 2", len(events))
+	}
+	if !events[1].Holiday || events[1].EventText != "Новый год" {
+		t.Errorf("GetEventsForDay() virtual event = %+v", events[1])
+	}
+
+	// Перенесённые рабочие дни не попадают в список событий
+	events, _ = service.GetEventsForMonth(1, "2024-04-01")
+	if len(events) != 0 {
+		t.Errorf("GetEventsForMonth() count = %v, want 0", len(events))
+	}
+
+	days, err := service.GetHolidays("2024-01-01", "2024-12-31")
+	if err != nil || len(days) != 2 {
+		t.Errorf("GetHolidays() = %v, %v, want 2 days", days, err)
+	}
+}
+
+func TestEventService_EventsOrderedByDate(t *testing.T) {
+	service := NewEventService()
+
+	service.CreateEvent(1, "2024-01-20", "Event 1")
+	service.CreateEvent(1, "2024-01-05", "Event 2")
+	moved, _ := service.CreateEvent(1, "2024-01-31", "Event 3")
+	service.CreateEvent(1, "2024-01-05", "Event 4")
+	service.UpdateEvent(moved.ID, 1, "2024-01-01", "Event 3")
+
+	events, _ := service.GetEventsForMonth(1, "2024-01-01")
+
+	want := []string{"Event 3", "Event 2", "Event 4", "Event 1"}
+	if len(events) != len(want) {
+		t.Fatalf("GetEventsForMonth() count = %v, want %v", len(events), len(want))
+	}
+	for i, event := range events {
+		if event.EventText != want[i] {
+			t.Errorf("GetEventsForMonth()[%d] = %v, want %v", i, event.EventText, want[i])
+		}
+	}
+}
+
+func TestEventService_HolidaysOrderedByDate(t *testing.T) {
+	service := NewEventService()
+
+	cal, err := holiday.Parse([]byte(`{"days": [
+		{"date": "2024-05-01", "name": "Праздник Весны и Труда"},
+		{"date": "2024-05-09", "name": "День Победы"},
+		{"date": "2024-05-10", "type": "workday"}
+	]}`))
+	if err != nil {
+		t.Fatalf("holiday.Parse() error = %v", err)
+	}
+	service.SetHolidayCalendar(cal, true)
+
+	service.CreateEvent(1, "2024-05-02", "Event 3")
+	service.CreateEvent(1, "2024-04-29", "Event 1")
+	service.CreateEvent(1, "2024-05-01", "Event 2")
+	service.CreateEvent(1, "2024-05-12", "Event 4")
+
+	tests := []struct {
+		name string
+		get  func() ([]*model.Event, error)
+		want []string
+	}{
+		{
+			name: "iso week",
+			get:  func() ([]*model.Event, error) { return service.GetEventsForISOWeek(1, "2024-W18") },
+			want: []string{"Event 1", "Event 2", "Праздник Весны и Труда", "Event 3"},
+		},
+		{
+			name: "month",
+			get:  func() ([]*model.Event, error) { return service.GetEventsForMonth(1, "2024-05-01") },
+			want: []string{"Event 2", "Праздник Весны и Труда", "Event 3", "День Победы", "Event 4"},
+		},
+		{
+			name: "day",
+			get:  func() ([]*model.Event, error) { return service.GetEventsForDay(1, "2024-05-01") },
+			want: []string{"Event 2", "Праздник Весны и Труда"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := tt.get()
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			var got []string
+			for i, event := range events {
+				got = append(got, event.EventText)
+				if i > 0 && event.Date.Before(events[i-1].Date) {
+					t.Errorf("event %d (%v) is before event %d (%v)", i, event.Date, i-1, events[i-1].Date)
+				}
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventService_UpdateMovesEventBetweenUsers(t *testing.T) {
+	service := NewEventService()
+
+	event, _ := service.CreateEvent(1, "2024-01-01", "Event")
+	if _, err := service.UpdateEvent(event.ID, 2, "2024-01-02", "Event"); err != nil {
+		t.Fatalf("UpdateEvent() error = %v", err)
+	}
+
+	if events, _ := service.GetEventsForDay(1, "2024-01-01"); len(events) != 0 {
+		t.Errorf("old user still has %v events", len(events))
+	}
+	if events, _ := service.GetEventsForDay(2, "2024-01-02"); len(events) != 1 {
+		t.Errorf("new user has %v events, want 1", len(events))
+	}
+	if ids := service.UserIDs(); len(ids) != 1 || ids[0] != 2 {
+		t.Errorf("UserIDs() = %v, want [2]", ids)
+	}
+}
+
+func TestEventService_ConcurrentMixedAccess(t *testing.T) {
+	service := NewEventService()
+
+	var wg sync.WaitGroup
+	for worker := 1; worker <= 8; worker++ {
+		wg.Add(1)
+		go func(userID int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				event, err := service.CreateEvent(userID, "2024-01-01", "Event")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				// Переносим событие другому пользователю, чтобы блокировки шардов пересекались
+				service.UpdateEvent(event.ID, userID%8+1, "2024-01-02", "Moved")
+				service.GetEventsForWeek(userID, "2024-01-01")
+				if i%2 == 0 {
+					service.DeleteEvent(event.ID)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	total := 0
+	for userID := 1; userID <= 8; userID++ {
+		events, _ := service.GetEventsForDay(userID, "2024-01-02")
+		total += len(events)
+	}
+	if total != 400 {
+		t.Errorf("events after concurrent access = %v, want 400", total)
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"calendar/internal/model"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// shardCount is the number of independently locked partitions of the store
+const shardCount = 32
+
+// userIndex keeps events of one user ordered by the wall-clock date
+// in their own timezone and then by ID
+type userIndex []*model.Event
+
+// search returns the position of the first event not before (date, id).
+// Only the wall-clock part of date is compared
+func (idx userIndex) search(date time.Time, id int) int {
+	date = wallClock(date)
+	return sort.Search(len(idx), func(i int) bool {
+		e := idx[i]
+		if local := wallClock(e.Date); !local.Equal(date) {
+			return local.After(date)
+		}
+		return e.ID >= id
+	})
+}
+
+func (idx userIndex) insert(event *model.Event) userIndex {
+	i := idx.search(event.Date, event.ID)
+	idx = append(idx, nil)
+	copy(idx[i+1:], idx[i:])
+	idx[i] = event
+	return idx
+}
+
+func (idx userIndex) remove(event *model.Event) userIndex {
+	i := idx.search(event.Date, event.ID)
+	if i < len(idx) && idx[i].ID == event.ID {
+		copy(idx[i:], idx[i+1:])
+		idx[len(idx)-1] = nil
+		idx = idx[:len(idx)-1]
+	}
+	return idx
+}
+
+// between returns a copy of events with wall-clock dates in [start, end)
+func (idx userIndex) between(start, end time.Time) []*model.Event {
+	from := idx.search(start, 0)
+	end = wallClock(end)
+	to := from + sort.Search(len(idx)-from, func(i int) bool {
+		return !wallClock(idx[from+i].Date).Before(end)
+	})
+	if from == to {
+		return nil
+	}
+	return append([]*model.Event(nil), idx[from:to]...)
+}
+
+// onDay returns a copy of events on the calendar day of date
+func (idx userIndex) onDay(date time.Time) []*model.Event {
+	var result []*model.Event
+	for i := idx.search(date, 0); i < len(idx) && isSameDay(idx[i].Date, date); i++ {
+		result = append(result, idx[i])
+	}
+	return result
+}
+
+type userShard struct {
+	mu    sync.RWMutex
+	users map[int]userIndex
+}
+
+type idShard struct {
+	mu     sync.Mutex
+	events map[int]*model.Event
+}
+
+// store holds events sharded by user for range queries and by ID for lookups.
+// Locks are always taken in the order: ID shard, then user shards by index.
+// Stored events are never mutated, updates replace them with a new copy
+type store struct {
+	lastID atomic.Int64
+	ids    [shardCount]idShard
+	users  [shardCount]userShard
+}
+
+func newStore() *store {
+	st := &store{}
+	for i := range st.ids {
+		st.ids[i].events = make(map[int]*model.Event)
+		st.users[i].users = make(map[int]userIndex)
+	}
+	return st
+}
+
+func (st *store) idShard(id int) *idShard {
+	return &st.ids[uint(id)%shardCount]
+}
+
+func (st *store) userShard(userID int) *userShard {
+	return &st.users[uint(userID)%shardCount]
+}
+
+func (st *store) create(userID int, date time.Time, eventText string) *model.Event {
+	event := &model.Event{
+		ID:        int(st.lastID.Add(1)),
+		UserID:    userID,
+		Date:      date,
+		EventText: eventText,
+	}
+
+	ids := st.idShard(event.ID)
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+
+	users := st.userShard(userID)
+	users.mu.Lock()
+	users.users[userID] = users.users[userID].insert(event)
+	users.mu.Unlock()
+
+	ids.events[event.ID] = event
+
+	return event
+}
+
+func (st *store) update(id, userID int, date time.Time, eventText string) (*model.Event, error) {
+	ids := st.idShard(id)
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+
+	old, exists := ids.events[id]
+	if !exists {
+		return nil, ErrEventNotFound
+	}
+
+	event := &model.Event{
+		ID:        id,
+		UserID:    userID,
+		Date:      date,
+		EventText: eventText,
+	}
+
+	unlock := st.lockUsers(old.UserID, userID)
+	from := st.userShard(old.UserID)
+	from.users[old.UserID] = from.users[old.UserID].remove(old)
+	if len(from.users[old.UserID]) == 0 {
+		delete(from.users, old.UserID)
+	}
+	to := st.userShard(userID)
+	to.users[userID] = to.users[userID].insert(event)
+	unlock()
+
+	ids.events[id] = event
+
+	return event, nil
+}
+
+func (st *store) delete(id int) error {
+	ids := st.idShard(id)
+	ids.mu.Lock()
+	defer ids.mu.Unlock()
+
+	event, exists := ids.events[id]
+	if !exists {
+		return ErrEventNotFound
+	}
+
+	users := st.userShard(event.UserID)
+	users.mu.Lock()
+	users.users[event.UserID] = users.users[event.UserID].remove(event)
+	if len(users.users[event.UserID]) == 0 {
+		delete(users.users, event.UserID)
+	}
+	users.mu.Unlock()
+
+	delete(ids.events, id)
+
+	return nil
+}
+
+// lockUsers locks the shards of both users in index order and returns the unlock function
+func (st *store) lockUsers(userA, userB int) func() {
+	a, b := uint(userA)%shardCount, uint(userB)%shardCount
+	if a > b {
+		a, b = b, a
+	}
+
+	st.users[a].mu.Lock()
+	if a == b {
+		return st.users[a].mu.Unlock
+	}
+	st.users[b].mu.Lock()
+
+	return func() {
+		st.users[b].mu.Unlock()
+		st.users[a].mu.Unlock()
+	}
+}
+
+func (st *store) between(userID int, start, end time.Time) []*model.Event {
+	users := st.userShard(userID)
+	users.mu.RLock()
+	defer users.mu.RUnlock()
+
+	return users.users[userID].between(start, end)
+}
+
+func (st *store) onDay(userID int, date time.Time) []*model.Event {
+	users := st.userShard(userID)
+	users.mu.RLock()
+	defer users.mu.RUnlock()
+
+	return users.users[userID].onDay(date)
+}
+
+func (st *store) userIDs() []int {
+	var ids []int
+	for i := range st.users {
+		users := &st.users[i]
+		users.mu.RLock()
+		for id := range users.users {
+			ids = append(ids, id)
+		}
+		users.mu.RUnlock()
+	}
+	sort.Ints(ids)
+	return ids
+}